@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"image/color"
 	"log"
@@ -15,6 +16,7 @@ import (
 	"gioui.org/unit"
 	"gioui.org/widget"
 	"gioui.org/widget/material"
+	"github.com/mdouchement/gexplorer"
 	"github.com/mdouchement/gexplorer/gioexplorer"
 )
 
@@ -100,77 +102,52 @@ func (ui *UI) loop() error {
 }
 
 func (ui *UI) events() error {
-	if ui.createFile.Clicked() {
-		go func() {
-			filename, err := ui.explorer.CreateFile("default-name.txt")
-			if err != nil {
-				err = fmt.Errorf("failed creating image file: %w", err)
-				fmt.Println(err)
-				return
-			}
+	// The callbacks are called on the event loop of the window, so they can safely update the UI state.
 
-			ui.filenames = ui.filenames[:0]
-			ui.filenames = append(ui.filenames, filename)
-		}()
+	if ui.createFile.Clicked() {
+		ui.explorer.CreateFileAsync(context.Background(), "default-name.txt", func(result gexplorer.Result) {
+			ui.show("failed creating image file", []string{result.Path()}, result.Err)
+		})
 	}
 
 	if ui.chooseFile.Clicked() {
-		go func() {
-			filename, err := ui.explorer.ChooseFile()
-			if err != nil {
-				err = fmt.Errorf("failed opening file: %w", err)
-				fmt.Println(err)
-				return
-			}
-
-			ui.filenames = ui.filenames[:0]
-			ui.filenames = append(ui.filenames, filename)
-		}()
+		ui.explorer.ChooseFileAsync(context.Background(), func(result gexplorer.Result) {
+			ui.show("failed opening file", []string{result.Path()}, result.Err)
+		})
 	}
 
 	if ui.chooseFiles.Clicked() {
-		go func() {
-			var err error
-
-			ui.filenames, err = ui.explorer.ChooseFiles()
-			if err != nil {
-				err = fmt.Errorf("failed opening files: %w", err)
-				fmt.Println(err)
-				return
-			}
-		}()
+		ui.explorer.ChooseFilesAsync(context.Background(), func(result gexplorer.Result) {
+			ui.show("failed opening files", result.Paths(), result.Err)
+		})
 	}
 
 	if ui.chooseImage.Clicked() {
-		go func() {
-			filename, err := ui.explorer.ChooseFile("png", "jpeg", "jpg")
-			if err != nil {
-				err = fmt.Errorf("failed opening image file: %w", err)
-				fmt.Println(err)
-				return
-			}
-
-			ui.filenames = ui.filenames[:0]
-			ui.filenames = append(ui.filenames, filename)
-		}()
+		ui.explorer.ChooseFileAsync(context.Background(), func(result gexplorer.Result) {
+			ui.show("failed opening image file", []string{result.Path()}, result.Err)
+		}, "png", "jpeg", "jpg")
 	}
 
 	if ui.chooseImages.Clicked() {
-		go func() {
-			var err error
-
-			ui.filenames, err = ui.explorer.ChooseFiles("png", "jpeg", "jpg")
-			if err != nil {
-				err = fmt.Errorf("failed opening image files: %w", err)
-				fmt.Println(err)
-				return
-			}
-		}()
+		ui.explorer.ChooseFilesAsync(context.Background(), func(result gexplorer.Result) {
+			ui.show("failed opening image files", result.Paths(), result.Err)
+		}, "png", "jpeg", "jpg")
 	}
 
 	return nil
 }
 
+// show shows the given filenames, or prints the error.
+func (ui *UI) show(message string, filenames []string, err error) {
+	if err != nil {
+		fmt.Println(fmt.Errorf("%s: %w", message, err))
+		return
+	}
+
+	ui.filenames = filenames
+	ui.window.Invalidate()
+}
+
 func (ui *UI) layoutButton(gtx layout.Context) layout.Dimensions {
 	return layout.Flex{}.Layout(gtx,
 		layout.Flexed(1,
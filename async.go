@@ -0,0 +1,112 @@
+package gexplorer
+
+import "context"
+
+// Result is the result of an asynchronous dialog.
+type Result struct {
+	// Selection holds everything reported by the dialog, nil on error.
+	Selection *Selection
+	// Err is the error of the dialog, such as ErrUserDecline.
+	Err error
+}
+
+// Path returns the first selected path, or an empty string on error.
+func (r Result) Path() string {
+	if r.Selection == nil || len(r.Selection.Paths) == 0 {
+		return ""
+	}
+	return r.Selection.Paths[0]
+}
+
+// Paths returns the selected paths, or nil on error.
+func (r Result) Paths() []string {
+	if r.Selection == nil {
+		return nil
+	}
+	return r.Selection.Paths
+}
+
+// Handle is a dialog running asynchronously.
+type Handle struct {
+	cancel context.CancelFunc
+	result chan Result
+}
+
+// Result returns the channel receiving the result of the dialog, only once.
+func (h *Handle) Result() <-chan Result {
+	return h.result
+}
+
+// Cancel dismisses the dialog, its result is then the context.Canceled error.
+func (h *Handle) Cancel() {
+	h.cancel()
+}
+
+// ChooseFileAsync is like ChooseFileContext but it returns immediately.
+// The result is delivered by the returned Handle and, when not nil, given to the callback.
+//
+// The callback is called through the RunHandler of the Explorer, so it can safely update
+// the UI state. Without RunHandler, it's called on its own goroutine.
+func (e *Explorer) ChooseFileAsync(ctx context.Context, callback func(Result), extensions ...string) *Handle {
+	return e.OpenAsync(ctx, &Options{Extensions: extensions}, callback)
+}
+
+// ChooseFilesAsync is like ChooseFilesContext but it returns immediately.
+// The result is delivered like ChooseFileAsync.
+func (e *Explorer) ChooseFilesAsync(ctx context.Context, callback func(Result), extensions ...string) *Handle {
+	return e.OpenAsync(ctx, &Options{Extensions: extensions, Multiple: true}, callback)
+}
+
+// CreateFileAsync is like CreateFileContext but it returns immediately.
+// The result is delivered like ChooseFileAsync.
+func (e *Explorer) CreateFileAsync(ctx context.Context, name string, callback func(Result)) *Handle {
+	return e.SaveAsync(ctx, &Options{Name: name}, callback)
+}
+
+// OpenAsync is like Open but it returns immediately.
+// The result is delivered like ChooseFileAsync.
+func (e *Explorer) OpenAsync(ctx context.Context, opts *Options, callback func(Result)) *Handle {
+	return e.async(ctx, callback, func(ctx context.Context) (*Selection, error) {
+		return e.Open(ctx, opts)
+	})
+}
+
+// SaveAsync is like Save but it returns immediately.
+// The result is delivered like ChooseFileAsync.
+func (e *Explorer) SaveAsync(ctx context.Context, opts *Options, callback func(Result)) *Handle {
+	return e.async(ctx, callback, func(ctx context.Context) (*Selection, error) {
+		return e.Save(ctx, opts)
+	})
+}
+
+// async runs the given dialog on its own goroutine and delivers its result.
+func (e *Explorer) async(ctx context.Context, callback func(Result), dialog func(ctx context.Context) (*Selection, error)) *Handle {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &Handle{
+		cancel: cancel,
+		result: make(chan Result, 1),
+	}
+
+	var run RunHandler
+	if e != nil {
+		run = e.run
+	}
+
+	go func() {
+		defer cancel()
+
+		selection, err := dialog(ctx)
+		result := Result{Selection: selection, Err: err}
+		h.result <- result
+
+		switch {
+		case callback == nil:
+		case run != nil:
+			run(func() { callback(result) })
+		default:
+			callback(result)
+		}
+	}()
+
+	return h
+}
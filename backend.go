@@ -0,0 +1,197 @@
+package gexplorer
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"unsafe"
+)
+
+// Backend implements the dialogs used by an Explorer.
+//
+// The given options are never nil. A backend ignores the options it doesn't support and
+// returns ErrNotAvailable for the dialogs it can't show. ErrUserDecline is returned
+// when the user doesn't select anything, so a selection returned without error holds at least one path.
+// An empty selection is handled as ErrUserDecline by the Explorer.
+type Backend interface {
+	// OpenFile lets the user select a single file.
+	OpenFile(ctx context.Context, opts *Options) (*Selection, error)
+	// OpenFiles lets the user select multiple files.
+	OpenFiles(ctx context.Context, opts *Options) (*Selection, error)
+	// SaveFile lets the user choose the location of a file to create.
+	SaveFile(ctx context.Context, opts *Options) (*Selection, error)
+	// OpenDirectory lets the user select a directory, or several of them when Options.Multiple is set.
+	OpenDirectory(ctx context.Context, opts *Options) (*Selection, error)
+}
+
+// FilesSaver is implemented by the backends able to create several files with a single dialog.
+// Otherwise, the user chooses the directory where the files are created.
+type FilesSaver interface {
+	// SaveFiles lets the user choose where the files with the given names are created.
+	SaveFiles(ctx context.Context, opts *Options, names []string) (*Selection, error)
+}
+
+// FileRevealer is implemented by the backends able to show files in the file manager.
+type FileRevealer interface {
+	// RevealFile opens the directory containing the given file in the file manager, the file being selected.
+	RevealFile(ctx context.Context, path string) error
+}
+
+// FileOpener is implemented by the backends able to open files with an application.
+type FileOpener interface {
+	// OpenWith opens the given file with its default application,
+	// or with the one chosen by the user when ask is set.
+	OpenWith(ctx context.Context, path string, ask bool) error
+}
+
+// ViewSetter is implemented by the backends attaching the dialogs to a window.
+type ViewSetter interface {
+	// SetView sets the view/window used as parent of the dialogs.
+	SetView(v uintptr)
+}
+
+// WaylandViewSetter is implemented by the backends attaching the dialogs to a Wayland surface.
+type WaylandViewSetter interface {
+	// SetWaylandView sets the Wayland surface used as parent of the dialogs.
+	SetWaylandView(display, surface unsafe.Pointer)
+}
+
+// BackendFactory creates a Backend for the given RunHandler.
+type BackendFactory func(run RunHandler) Backend
+
+var backends = struct {
+	sync.RWMutex
+	factories map[string]BackendFactory
+}{
+	factories: map[string]BackendFactory{},
+}
+
+// RegisterBackend makes a backend available by the given name.
+// Registering twice the same name replaces the previous backend.
+//
+// The backends of the current OS are registered by default (such as `portal` on Linux,
+// `win32` on Windows and `appkit` on macOS).
+func RegisterBackend(name string, factory BackendFactory) {
+	backends.Lock()
+	defer backends.Unlock()
+
+	backends.factories[name] = factory
+}
+
+// Backends returns the names of the registered backends.
+func Backends() []string {
+	backends.RLock()
+	defer backends.RUnlock()
+
+	names := make([]string, 0, len(backends.factories))
+	for name := range backends.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewBackend creates the backend registered by the given name.
+// ErrNotAvailable is returned when no backend is registered by this name.
+func NewBackend(name string, run RunHandler) (Backend, error) {
+	backends.RLock()
+	factory, ok := backends.factories[name]
+	backends.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown backend %q", ErrNotAvailable, name)
+	}
+	return factory(run), nil
+}
+
+// unavailable is the backend used when the current OS isn't supported.
+type unavailable struct{}
+
+func (unavailable) OpenFile(context.Context, *Options) (*Selection, error) {
+	return nil, ErrNotAvailable
+}
+
+func (unavailable) OpenFiles(context.Context, *Options) (*Selection, error) {
+	return nil, ErrNotAvailable
+}
+
+func (unavailable) SaveFile(context.Context, *Options) (*Selection, error) {
+	return nil, ErrNotAvailable
+}
+
+func (unavailable) OpenDirectory(context.Context, *Options) (*Selection, error) {
+	return nil, ErrNotAvailable
+}
+
+// open calls the method of the backend selecting files or directories
+// according to Options.Multiple and Options.Directory.
+func open(ctx context.Context, backend Backend, opts *Options) (*Selection, error) {
+	switch {
+	case opts.Directory:
+		return selected(backend.OpenDirectory(ctx, opts))
+	case opts.Multiple:
+		return selected(backend.OpenFiles(ctx, opts))
+	default:
+		return selected(backend.OpenFile(ctx, opts))
+	}
+}
+
+// selected returns the given selection of a backend, or ErrUserDecline when it's empty.
+func selected(selection *Selection, err error) (*Selection, error) {
+	if err != nil {
+		return nil, err
+	}
+	if selection == nil || len(selection.Paths) == 0 {
+		return nil, ErrUserDecline
+	}
+	return selection, nil
+}
+
+// saveFilesInDirectory lets the user choose a directory where the files with the given names are created.
+func saveFilesInDirectory(ctx context.Context, backend Backend, opts *Options, names []string) (*Selection, error) {
+	selection, err := selected(backend.OpenDirectory(ctx, withMode(opts, false, true)))
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(selection.Paths[0], name)
+	}
+
+	selection = NewSelection(paths)
+	selection.Writable = true
+	return selection, nil
+}
+
+// openLabel returns the default title of a dialog selecting files or directories.
+func openLabel(opts *Options) string {
+	switch {
+	case opts.Directory && opts.Multiple:
+		return "Choose Directories"
+	case opts.Directory:
+		return "Choose Directory"
+	case opts.Multiple:
+		return "Choose Files"
+	default:
+		return "Choose File"
+	}
+}
+
+// title returns the title of the dialog, or the given label when it isn't defined.
+func title(opts *Options, label string) string {
+	if opts.Title != "" {
+		return opts.Title
+	}
+	return label
+}
+
+// withMode returns a copy of the given options having the given Multiple and Directory fields.
+func withMode(opts *Options, multiple, directory bool) *Options {
+	o := *opts
+	o.Multiple = multiple
+	o.Directory = directory
+	return &o
+}
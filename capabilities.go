@@ -0,0 +1,72 @@
+package gexplorer
+
+// Capabilities describes the dialogs and options supported by a backend,
+// so unsupported features can be hidden instead of failing.
+type Capabilities struct {
+	// OpenFile reports whether a single file can be selected.
+	OpenFile bool
+	// OpenFiles reports whether multiple files can be selected.
+	OpenFiles bool
+	// SaveFile reports whether the location of a file to create can be chosen.
+	SaveFile bool
+	// SaveFiles reports whether several files can be created at once, possibly by choosing a directory.
+	SaveFiles bool
+	// OpenDirectory reports whether a single directory can be selected.
+	OpenDirectory bool
+	// OpenDirectories reports whether multiple directories can be selected.
+	OpenDirectories bool
+
+	// RevealFile reports whether files can be shown in the file manager.
+	RevealFile bool
+	// OpenWith reports whether files can be opened with their default application.
+	OpenWith bool
+	// OpenWithChooser reports whether the user can be asked which application opens a file.
+	OpenWithChooser bool
+
+	// Filters reports whether the selectable files can be restricted by Options.Extensions and Options.Filters.
+	Filters bool
+	// FilterSelection reports whether the user can switch between the filters, the picked one being reported.
+	FilterSelection bool
+	// Choices reports whether Options.Choices are displayed.
+	Choices bool
+	// Folder reports whether Options.Folder is supported by all the dialogs.
+	Folder bool
+	// AcceptLabel reports whether Options.AcceptLabel is supported.
+	AcceptLabel bool
+	// Modal reports whether the dialogs can be modal to the parent window.
+	Modal bool
+}
+
+// CapabilitiesReporter is implemented by the backends reporting their capabilities.
+type CapabilitiesReporter interface {
+	// Capabilities returns the capabilities of the backend, or ErrNotAvailable when it can't show any dialog.
+	Capabilities() (Capabilities, error)
+}
+
+// Capabilities returns which dialogs and options the backend of the Explorer supports.
+// ErrNotAvailable is returned when no dialog can be shown.
+//
+// When the backend doesn't implement CapabilitiesReporter, only the dialogs are reported.
+func (e *Explorer) Capabilities() (Capabilities, error) {
+	if e == nil {
+		return Capabilities{}, ErrNotAvailable
+	}
+
+	if reporter, ok := e.backend.(CapabilitiesReporter); ok {
+		return reporter.Capabilities()
+	}
+
+	return Capabilities{
+		OpenFile:        true,
+		OpenFiles:       true,
+		SaveFile:        true,
+		SaveFiles:       true,
+		OpenDirectory:   true,
+		OpenDirectories: true,
+	}, nil
+}
+
+// Capabilities implements CapabilitiesReporter.
+func (unavailable) Capabilities() (Capabilities, error) {
+	return Capabilities{}, ErrNotAvailable
+}
@@ -0,0 +1,47 @@
+package gexplorer
+
+// Choice is an extra widget displayed in the dialog, either a checkbox or a combo box.
+// Its final value is reported in Selection.Choices.
+//
+// Choices are only supported by the Linux backend.
+type Choice struct {
+	// ID identifies the choice in Selection.Choices.
+	ID string
+	// Label is the text displayed next to the widget (such as "Include metadata").
+	Label string
+	// Options are the entries of the combo box.
+	// When empty, the choice is a checkbox whose value is either "true" or "false".
+	Options []ChoiceOption
+	// Default is the ID of the option initially selected, or "true"/"false" for a checkbox.
+	Default string
+}
+
+// ChoiceOption is an entry of a combo box.
+type ChoiceOption struct {
+	// ID identifies the option, it's the value of the choice when selected.
+	ID string
+	// Label is the text displayed for the option (such as "UTF-8").
+	Label string
+}
+
+// defaultValue returns the value of the choice when the user didn't change it.
+func (c Choice) defaultValue() string {
+	if c.Default == "" && len(c.Options) == 0 {
+		return "false"
+	}
+	return c.Default
+}
+
+// completeChoices sets the default value of the given choices missing from values,
+// so all the requested choices are reported.
+func completeChoices(values map[string]string, choices []Choice) map[string]string {
+	for _, choice := range choices {
+		if values == nil {
+			values = make(map[string]string, len(choices))
+		}
+		if _, ok := values[choice.ID]; !ok {
+			values[choice.ID] = choice.defaultValue()
+		}
+	}
+	return values
+}
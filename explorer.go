@@ -5,10 +5,16 @@
 package gexplorer
 
 import (
+	"context"
 	"errors"
-	"runtime"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
-	"sync/atomic"
+	"unsafe"
 )
 
 var (
@@ -17,59 +23,252 @@ var (
 
 	// ErrNotAvailable is return when the current OS isn't supported.
 	ErrNotAvailable = errors.New("current OS not supported")
+
+	// ErrDialogFailed is returned when the file selector failed for another reason than the user cancellation.
+	ErrDialogFailed = errors.New("file selector failed")
+
+	// ErrBusy is returned when a dialog is requested while another one is shown by the same Explorer,
+	// using the RejectBusy policy.
+	ErrBusy = errors.New("another file selector is already shown")
+
+	// ErrFilterMismatch is returned in strict mode when the selected files don't match the requested file types.
+	ErrFilterMismatch = errors.New("selected files don't match the requested file types")
 )
 
+// PortalError is returned when the desktop portal reports a failure.
+// It matches ErrDialogFailed using errors.Is.
+type PortalError struct {
+	// Code is the response code of the request, 0 when the failure comes from a D-Bus call.
+	Code uint32
+	// Name is the D-Bus error name, empty when the failure comes from the response code.
+	Name string
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *PortalError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("%s: %s: %v", ErrDialogFailed, e.Name, e.Err)
+	}
+	return fmt.Sprintf("%s: response code %d", ErrDialogFailed, e.Code)
+}
+
+// Is reports whether target is ErrDialogFailed.
+func (e *PortalError) Is(target error) bool {
+	return target == ErrDialogFailed
+}
+
+// Unwrap returns the underlying error.
+func (e *PortalError) Unwrap() error {
+	return e.Err
+}
+
+// FilterMismatchError is returned in strict mode when some of the selected files
+// don't match the file types of Options.Extensions and Options.Filters.
+// It matches ErrFilterMismatch using errors.Is.
+type FilterMismatchError struct {
+	// Paths are the selected files not matching the requested file types.
+	Paths []string
+	// Selection is the whole selection reported by the dialog.
+	Selection *Selection
+}
+
+func (e *FilterMismatchError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrFilterMismatch, strings.Join(e.Paths, ", "))
+}
+
+// Is reports whether target is ErrFilterMismatch.
+func (e *FilterMismatchError) Is(target error) bool {
+	return target == ErrFilterMismatch
+}
+
 // RunHandler allows to run a function in the context of another thread.
 // Mainly used for https://pkg.go.dev/gioui.org@v0.0.0-20230502183330-59695984e53c/app#Window.Run
 type RunHandler func(func())
 
+// Options customizes the dialogs. The zero value uses the OS defaults.
+//
+// Not all OSes support every option, unsupported ones are ignored.
+type Options struct {
+	// Title is the title of the dialog (such as "Import dataset").
+	Title string
+	// AcceptLabel is the label of the accept button (such as "Import").
+	AcceptLabel string
+	// Modeless allows to interact with the parent window while the dialog is opened.
+	Modeless bool
+	// Folder is the directory where the dialog is opened.
+	Folder string
+	// Name is the suggested name of the file to create.
+	Name string
+	// File is the path of an existing file to save. It's only used when creating a file.
+	File string
+	// Multiple allows the user to select multiple files or directories. It's only used by Open.
+	Multiple bool
+	// Directory makes the dialog select directories instead of files. It's only used by Open.
+	Directory bool
+	// Extensions defines which file extensions is supported to be selected (such as `.jpg`, `.png`).
+	// Glob patterns (such as `*.tar.gz`) and MIME types (such as `text/csv` or `image/*`) can be mixed in.
+	// They are matched case-insensitively and grouped in a filter displayed before the ones of Filters.
+	Extensions []string
+	// Filters are the named groups of file types the user can switch between.
+	Filters []Filter
+	// CurrentFilter is the filter selected when the dialog is opened, it's matched by name against Filters.
+	// Once the dialog returns, it holds the filter picked by the user if the OS reports it.
+	CurrentFilter *Filter
+	// Choices are extra checkboxes and combo boxes displayed in the dialog.
+	// Their final values are reported in Selection.Choices.
+	Choices []Choice
+	// Purpose identifies what the dialog is used for (such as "import-csv" or "export-report").
+	// When defined, the folder of the selection is remembered under `$XDG_STATE_HOME`, and the next
+	// dialog having the same purpose is opened there unless Folder or File is defined.
+	Purpose string
+	// Strict checks the selected files against the file types of Extensions and Filters, since the user
+	// can switch to "All files" or type any name. A FilterMismatchError is returned when some don't match.
+	// It's only used by Open and Save.
+	Strict bool
+	// SniffContent also checks the content of the selected files in strict mode, a file whose content
+	// contradicts its extension being rejected and a file whose content has a requested type being accepted.
+	// It's only used by Open.
+	SniffContent bool
+}
+
+// Selection is the result of a dialog.
+type Selection struct {
+	// Paths are the paths of the selected files or directories.
+	Paths []string
+	// URIs are the selected files or directories as reported by the OS (such as `file:///tmp/file.txt`).
+	URIs []string
+	// Filter is the filter picked by the user, nil if the OS doesn't report it.
+	Filter *Filter
+	// Choices holds the final value of each choice of the dialog, by choice identifier.
+	Choices map[string]string
+	// Writable reports whether the selected files can be written.
+	Writable bool
+}
+
 type result struct {
 	filenames []string
+	uris      []string
 	error     error
 }
 
+// BusyPolicy defines how an Explorer handles a dialog requested while another one is shown.
+type BusyPolicy int
+
+const (
+	// QueueBusy shows the dialog once the previous ones are done, in request order.
+	QueueBusy BusyPolicy = iota
+	// RejectBusy returns ErrBusy.
+	RejectBusy
+)
+
 // Explorer facilitates opening OS-native dialogs to choose files and create files.
+// Only one dialog is shown at the same time by an Explorer, see SetBusyPolicy.
 type Explorer struct {
-	id    int32
-	mutex sync.Mutex
+	mutex   sync.Mutex
+	policy  BusyPolicy
+	busy    bool
+	waiters []chan struct{} // The dialogs waiting for their turn, in request order.
+
+	run RunHandler
+
+	// application is the name used to record the recently used files, empty when disabled.
+	application string
 
-	// explorer holds OS-Specific content, it varies for each OS.
-	*explorer
+	// backend shows the dialogs, it varies for each OS by default.
+	backend Backend
 }
 
-// active holds all explorer currently active, that may necessary for callback functions.
+// active holds all backends currently active, that may necessary for callback functions.
 //
 // Some OSes (Android, iOS, macOS) may call Golang exported functions as callback, but we need
-// someway to link that callback with the respective explorer, in order to give them a response.
+// someway to link that callback with the respective backend, in order to give them a response.
 //
-// In that case, a construction like `callback(..., id int32)` is used. Then, it's possible to get the explorer
+// In that case, a construction like `callback(..., id int32)` is used. Then, it's possible to get the backend
 // by lookup the active using the callback id.
 //
-// To avoid hold dead/unnecessary explorer, the active will be removed using `runtime.SetFinalizer` on the related
-// Explorer.
+// To avoid hold dead/unnecessary backend, they are stored as weak pointers and removed from the active
+// using `runtime.AddCleanup`.
 var (
-	active  = sync.Map{} // map[int32]*explorer
+	active  = sync.Map{} // map[int32]weak.Pointer
 	counter = new(int32)
 )
 
-// NewExplorer creates a new Explorer for the given RunHandler.
+// NewExplorer creates a new Explorer for the given RunHandler, using the default backend of the current OS.
+// When the current OS isn't supported, the `terminal` backend is used if stdin is a terminal.
 // The given RunHandler must be unique and you should call NewExplorer
 // once per new RunHandler.
-func NewExplorer(run RunHandler) (e *Explorer) {
-	e = &Explorer{
-		explorer: newExplorer(run),
-		id:       atomic.AddInt32(counter, 1),
+func NewExplorer(run RunHandler) *Explorer {
+	backend, err := NewBackend(defaultBackend, run)
+	switch {
+	case err == nil:
+	case stdinIsTerminal():
+		backend = stdinTerminal()
+	default:
+		backend = unavailable{}
 	}
 
-	active.Store(e.id, e.explorer)
-	runtime.SetFinalizer(e, func(e *Explorer) { active.Delete(e.id) })
+	return NewExplorerWithBackend(run, backend)
+}
 
-	return e
+// NewExplorerWithBackend creates a new Explorer showing the dialogs of the given backend.
+// The given RunHandler is the one of the application, it may be nil.
+func NewExplorerWithBackend(run RunHandler, backend Backend) *Explorer {
+	return &Explorer{
+		run:     run,
+		backend: backend,
+	}
+}
+
+// Close releases the resources held by the backend, such as the D-Bus connection of the Linux one.
+// The Explorer can still be used afterwards, the resources are acquired again when needed.
+func (e *Explorer) Close() error {
+	if e == nil {
+		return nil
+	}
+
+	if closer, ok := e.backend.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// SetBusyPolicy defines how a dialog requested while another one is shown is handled.
+// The default policy is QueueBusy.
+func (e *Explorer) SetBusyPolicy(policy BusyPolicy) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.policy = policy
 }
 
 // SetView sets the view/window to the dialod interface.
+// It's ignored when the backend doesn't implement ViewSetter.
 func (e *Explorer) SetView(v uintptr) {
-	e.setView(v)
+	if setter, ok := e.backend.(ViewSetter); ok {
+		setter.SetView(v)
+	}
+}
+
+// SetWaylandView sets the Wayland surface used as parent of the dialog interface.
+// The surface is exported using xdg-foreign, so the compositor must support it.
+// It's ignored when the backend doesn't implement WaylandViewSetter (only the Linux one does).
+func (e *Explorer) SetWaylandView(display, surface unsafe.Pointer) {
+	if setter, ok := e.backend.(WaylandViewSetter); ok {
+		setter.SetWaylandView(display, surface)
+	}
+}
+
+// SetFallbacks sets the command-line dialogs used, by order of preference, when no desktop portal
+// is available (such as on minimal window managers). Supported commands are `zenity`, `kdialog` and `yad`,
+// they can be given as absolute paths, and `terminal` which uses the terminal picker when stdin is a terminal.
+// Without commands, ErrNotAvailable is returned instead.
+//
+// The default chain is zenity, kdialog, yad then terminal. It's only used by the Linux `portal` backend.
+func (e *Explorer) SetFallbacks(commands ...string) {
+	if setter, ok := e.backend.(interface{ SetFallbacks(commands ...string) }); ok {
+		setter.SetFallbacks(commands...)
+	}
 }
 
 // ChooseFile shows the file selector, allowing the user to select a single file.
@@ -81,14 +280,30 @@ func (e *Explorer) SetView(v uintptr) {
 //
 // In most known browsers, when user clicks cancel then this function never returns.
 //
-// It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
-// ChooseFile or CreateFile, can happen at the same time, for each Explorer.
+// It's a blocking call, you should call it on a separated goroutine. Only one
+// ChooseFile or CreateFile, can happen at the same time, for each Explorer (see SetBusyPolicy).
 func (e *Explorer) ChooseFile(extensions ...string) (string, error) {
+	return e.ChooseFileContext(context.Background(), extensions...)
+}
+
+// ChooseFileContext is like ChooseFile but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) ChooseFileContext(ctx context.Context, extensions ...string) (string, error) {
+	return e.ChooseFileWithOptions(ctx, &Options{Extensions: extensions})
+}
+
+// ChooseFileWithOptions is like ChooseFileContext but the dialog is customized by the given options.
+func (e *Explorer) ChooseFileWithOptions(ctx context.Context, opts *Options) (string, error) {
 	if e == nil {
 		return "", ErrNotAvailable
 	}
 
-	return e.importFile(extensions...)
+	selection, err := e.openWith(ctx, opts, false, false)
+	if err != nil {
+		return "", err
+	}
+
+	return selection.Paths[0], nil
 }
 
 // ChooseFiles shows the files selector, allowing the user to select multiple files.
@@ -100,25 +315,340 @@ func (e *Explorer) ChooseFile(extensions ...string) (string, error) {
 //
 // In most known browsers, when user clicks cancel then this function never returns.
 //
-// It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
-// ChooseFile{,s} or CreateFile, can happen at the same time, for each Explorer.
+// It's a blocking call, you should call it on a separated goroutine. Only one
+// ChooseFile{,s} or CreateFile, can happen at the same time, for each Explorer (see SetBusyPolicy).
 func (e *Explorer) ChooseFiles(extensions ...string) ([]string, error) {
+	return e.ChooseFilesContext(context.Background(), extensions...)
+}
+
+// ChooseFilesContext is like ChooseFiles but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) ChooseFilesContext(ctx context.Context, extensions ...string) ([]string, error) {
+	return e.ChooseFilesWithOptions(ctx, &Options{Extensions: extensions})
+}
+
+// ChooseFilesWithOptions is like ChooseFilesContext but the dialog is customized by the given options.
+func (e *Explorer) ChooseFilesWithOptions(ctx context.Context, opts *Options) ([]string, error) {
 	if e == nil {
 		return nil, ErrNotAvailable
 	}
 
-	return e.importFiles(extensions...)
+	selection, err := e.openWith(ctx, opts, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return selection.Paths, nil
 }
 
 // CreateFile opens the file selector, and writes the given content into
 // some file, which the use can choose the location.
 //
-// It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
-// ChooseFile or CreateFile, can happen at the same time, for each Explorer.
+// It's a blocking call, you should call it on a separated goroutine. Only one
+// ChooseFile or CreateFile, can happen at the same time, for each Explorer (see SetBusyPolicy).
 func (e *Explorer) CreateFile(name string) (string, error) {
+	return e.CreateFileContext(context.Background(), name)
+}
+
+// CreateFileContext is like CreateFile but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) CreateFileContext(ctx context.Context, name string) (string, error) {
+	return e.CreateFileWithOptions(ctx, &Options{Name: name})
+}
+
+// CreateFileWithOptions is like CreateFileContext but the dialog is customized by the given options.
+// The suggested name of the file is defined by Options.Name.
+func (e *Explorer) CreateFileWithOptions(ctx context.Context, opts *Options) (string, error) {
+	if e == nil {
+		return "", ErrNotAvailable
+	}
+
+	selection, err := e.Save(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return selection.Paths[0], nil
+}
+
+// CreateFiles opens the directory selector, allowing the user to choose where
+// the files with the given names are created. It returns the path of each file,
+// in the same order as the given names.
+//
+// It's a blocking call, you should call it on a separated goroutine. Only one
+// dialog can happen at the same time, for each Explorer (see SetBusyPolicy).
+func (e *Explorer) CreateFiles(names ...string) ([]string, error) {
+	return e.CreateFilesContext(context.Background(), names...)
+}
+
+// CreateFilesContext is like CreateFiles but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) CreateFilesContext(ctx context.Context, names ...string) ([]string, error) {
+	return e.CreateFilesWithOptions(ctx, nil, names...)
+}
+
+// CreateFilesWithOptions is like CreateFilesContext but the dialog is customized by the given options.
+func (e *Explorer) CreateFilesWithOptions(ctx context.Context, opts *Options, names ...string) ([]string, error) {
+	selection, err := e.SaveFiles(ctx, opts, names)
+	if err != nil {
+		return nil, err
+	}
+
+	return selection.Paths, nil
+}
+
+// ChooseDirectory shows the directory selector, allowing the user to select a single directory.
+//
+// It's a blocking call, you should call it on a separated goroutine. Only one
+// ChooseFile{,s}, ChooseDirector{y,ies} or CreateFile, can happen at the same time, for each Explorer (see SetBusyPolicy).
+func (e *Explorer) ChooseDirectory() (string, error) {
+	return e.ChooseDirectoryContext(context.Background())
+}
+
+// ChooseDirectoryContext is like ChooseDirectory but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) ChooseDirectoryContext(ctx context.Context) (string, error) {
+	return e.ChooseDirectoryWithOptions(ctx, nil)
+}
+
+// ChooseDirectoryWithOptions is like ChooseDirectoryContext but the dialog is customized by the given options.
+func (e *Explorer) ChooseDirectoryWithOptions(ctx context.Context, opts *Options) (string, error) {
 	if e == nil {
 		return "", ErrNotAvailable
 	}
 
-	return e.exportFile(name)
+	selection, err := e.openWith(ctx, opts, false, true)
+	if err != nil {
+		return "", err
+	}
+
+	return selection.Paths[0], nil
+}
+
+// ChooseDirectories shows the directory selector, allowing the user to select multiple directories.
+//
+// On Windows, only a single directory can be selected so ErrNotAvailable is returned.
+//
+// It's a blocking call, you should call it on a separated goroutine. Only one
+// ChooseFile{,s}, ChooseDirector{y,ies} or CreateFile, can happen at the same time, for each Explorer (see SetBusyPolicy).
+func (e *Explorer) ChooseDirectories() ([]string, error) {
+	return e.ChooseDirectoriesContext(context.Background())
+}
+
+// ChooseDirectoriesContext is like ChooseDirectories but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) ChooseDirectoriesContext(ctx context.Context) ([]string, error) {
+	return e.ChooseDirectoriesWithOptions(ctx, nil)
+}
+
+// ChooseDirectoriesWithOptions is like ChooseDirectoriesContext but the dialog is customized by the given options.
+func (e *Explorer) ChooseDirectoriesWithOptions(ctx context.Context, opts *Options) ([]string, error) {
+	if e == nil {
+		return nil, ErrNotAvailable
+	}
+
+	selection, err := e.openWith(ctx, opts, true, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return selection.Paths, nil
+}
+
+// Open shows the file selector, allowing the user to select files or directories
+// according to Options.Multiple and Options.Directory.
+// The returned Selection holds everything reported by the dialog.
+//
+// It's a blocking call, you should call it on a separated goroutine. Only one
+// dialog can happen at the same time, for each Explorer (see SetBusyPolicy).
+func (e *Explorer) Open(ctx context.Context, opts *Options) (*Selection, error) {
+	if e == nil {
+		return nil, ErrNotAvailable
+	}
+
+	release, err := e.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	opts = options(opts)
+	selection, err := open(ctx, e.backend, lastFolder(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	if selection.Filter != nil {
+		opts.CurrentFilter = selection.Filter
+	}
+	if err := checkSelection(opts, selection, opts.SniffContent); err != nil {
+		return nil, err
+	}
+	e.recordRecent(selection.Paths)
+	rememberFolder(opts, selection)
+	return selection, nil
+}
+
+// Save opens the file selector, allowing the user to choose the location of a file to create.
+// The suggested name of the file is defined by Options.Name.
+// The returned Selection holds everything reported by the dialog.
+//
+// It's a blocking call, you should call it on a separated goroutine. Only one
+// dialog can happen at the same time, for each Explorer (see SetBusyPolicy).
+func (e *Explorer) Save(ctx context.Context, opts *Options) (*Selection, error) {
+	if e == nil {
+		return nil, ErrNotAvailable
+	}
+
+	release, err := e.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	opts = options(opts)
+	selection, err := selected(e.backend.SaveFile(ctx, lastFolder(opts)))
+	if err != nil {
+		return nil, err
+	}
+
+	if selection.Filter != nil {
+		opts.CurrentFilter = selection.Filter
+	}
+	if err := checkSelection(opts, selection, false); err != nil {
+		return nil, err
+	}
+	e.recordRecent(selection.Paths)
+	rememberFolder(opts, selection)
+	return selection, nil
+}
+
+// SaveFiles opens the directory selector, allowing the user to choose where
+// the files with the given names are created.
+// The returned Selection holds the path of each file, in the same order as the given names.
+//
+// When the backend doesn't implement FilesSaver, the user chooses a directory.
+//
+// It's a blocking call, you should call it on a separated goroutine. Only one
+// dialog can happen at the same time, for each Explorer (see SetBusyPolicy).
+func (e *Explorer) SaveFiles(ctx context.Context, opts *Options, names []string) (*Selection, error) {
+	if e == nil {
+		return nil, ErrNotAvailable
+	}
+
+	release, err := e.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	opts = options(opts)
+	saveFiles := func(opts *Options) (*Selection, error) {
+		if saver, ok := e.backend.(FilesSaver); ok {
+			return saver.SaveFiles(ctx, opts, names)
+		}
+		return saveFilesInDirectory(ctx, e.backend, opts, names)
+	}
+
+	selection, err := saveFiles(lastFolder(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	e.recordRecent(selection.Paths)
+	rememberFolder(opts, selection)
+	return selection, nil
+}
+
+// acquire waits for the turn of the caller to show a dialog, according to the busy policy.
+// The returned function must be called once the dialog is done.
+func (e *Explorer) acquire(ctx context.Context) (func(), error) {
+	e.mutex.Lock()
+	if !e.busy {
+		e.busy = true
+		e.mutex.Unlock()
+		return e.release, nil
+	}
+
+	if e.policy == RejectBusy {
+		e.mutex.Unlock()
+		return nil, ErrBusy
+	}
+
+	ready := make(chan struct{})
+	e.waiters = append(e.waiters, ready)
+	e.mutex.Unlock()
+
+	select {
+	case <-ready:
+		return e.release, nil
+	case <-ctx.Done():
+		e.mutex.Lock()
+		if i := slices.Index(e.waiters, ready); i >= 0 {
+			e.waiters = slices.Delete(e.waiters, i, i+1)
+			e.mutex.Unlock()
+			return nil, ctx.Err()
+		}
+		e.mutex.Unlock()
+
+		// The turn has been given meanwhile, so it's passed to the next one.
+		e.release()
+		return nil, ctx.Err()
+	}
+}
+
+// release gives the turn to the next waiting dialog, if any.
+func (e *Explorer) release() {
+	e.mutex.Lock()
+	if len(e.waiters) == 0 {
+		e.busy = false
+		e.mutex.Unlock()
+		return
+	}
+
+	next := e.waiters[0]
+	e.waiters = e.waiters[1:]
+	e.mutex.Unlock()
+
+	close(next)
+}
+
+// NewSelection returns the selection of the given paths, with their `file://` URIs.
+// It allows the implementations of Backend to build their selections.
+func NewSelection(paths []string) *Selection {
+	selection := &Selection{
+		Paths: paths,
+		URIs:  make([]string, len(paths)),
+	}
+
+	for i, path := range paths {
+		path = filepath.ToSlash(path)
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path // Such as `/C:/Users`.
+		}
+
+		uri := url.URL{Scheme: "file", Path: path}
+		selection.URIs[i] = uri.String()
+	}
+
+	return selection
+}
+
+// openWith calls Open with a copy of the given options having the given Multiple and Directory fields.
+func (e *Explorer) openWith(ctx context.Context, opts *Options, multiple, directory bool) (*Selection, error) {
+	o := withMode(options(opts), multiple, directory)
+
+	selection, err := e.Open(ctx, o)
+	if opts != nil {
+		opts.CurrentFilter = o.CurrentFilter
+	}
+	return selection, err
+}
+
+// options returns the given options or the default ones when nil.
+func options(opts *Options) *Options {
+	if opts == nil {
+		return new(Options)
+	}
+	return opts
 }
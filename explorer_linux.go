@@ -4,206 +4,417 @@
 package gexplorer
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"mime"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
+	"unicode"
+	"unsafe"
 
 	"github.com/godbus/dbus/v5"
 )
 
-// explorer opens file explorers using the xdg-desktop-portal dbus protocol
+// defaultBackend is the backend used by NewExplorer.
+const defaultBackend = "portal"
+
+func init() {
+	RegisterBackend("portal", func(_ RunHandler) Backend {
+		return newPortal()
+	})
+}
+
+// portal opens file explorers using the xdg-desktop-portal dbus protocol
 // defined here:
 // https://flatpak.github.io/xdg-desktop-portal/#gdbus-org.freedesktop.portal.FileChooser
-type explorer struct {
-	X11Window uintptr
+type portal struct {
+	// mutex protects the views, the fallbacks and the session bus connection, shared by all the dialogs.
+	mutex sync.Mutex
+
+	X11Window      uintptr
+	WaylandDisplay unsafe.Pointer
+	WaylandSurface unsafe.Pointer
+
+	// fallbacks are the command-line dialogs used when no desktop portal is available.
+	fallbacks []string
+
+	conn  *dbus.Conn
+	owned bool // The connection has been opened by the portal, which closes it.
 }
 
-func newExplorer(_ RunHandler) *explorer {
-	return new(explorer)
+// errNoDesktopPortal is returned when no service implements the desktop portal.
+var errNoDesktopPortal = errors.New("desktop portal not available")
+
+func newPortal() *portal {
+	return &portal{
+		fallbacks: slices.Clone(defaultFallbacks),
+	}
 }
 
-func (e *Explorer) setView(v uintptr) {
-	e.X11Window = v
+// NewPortalBackend returns the Linux `portal` backend using the given session bus connection,
+// for the applications already owning one. The connection isn't closed by the backend.
+// When nil or once disconnected, the backend connects to the session bus by itself.
+func NewPortalBackend(conn *dbus.Conn) Backend {
+	p := newPortal()
+	p.conn = conn
+	return p
 }
 
-func (e *Explorer) exportFile(name string) (string, error) {
-	var filename string
-	return filename, e.withDesktopPortal(func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error {
-		// Invoke the OpenFile method.
+// Close closes the session bus connection if it has been opened by the portal.
+// A new connection is opened by the next dialog.
+func (p *portal) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	conn, owned := p.conn, p.owned
+	p.conn, p.owned = nil, false
+	if conn == nil || !owned {
+		return nil
+	}
+	return conn.Close()
+}
+
+// connection returns the session bus connection, connecting to the session bus when
+// there isn't one yet or when it has been disconnected.
+func (p *portal) connection() (*dbus.Conn, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.conn != nil && p.conn.Connected() {
+		return p.conn, nil
+	}
+
+	if p.conn != nil && p.owned {
+		p.conn.Close() // Releases the resources of the broken connection.
+	}
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+
+	p.conn, p.owned = conn, true
+	return conn, nil
+}
+
+// SetView implements ViewSetter.
+func (p *portal) SetView(v uintptr) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.X11Window = v
+}
+
+// SetWaylandView implements WaylandViewSetter.
+func (p *portal) SetWaylandView(display, surface unsafe.Pointer) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.WaylandDisplay = display
+	p.WaylandSurface = surface
+}
+
+// SetFallbacks sets the command-line dialogs used when no desktop portal is available.
+func (p *portal) SetFallbacks(commands ...string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.fallbacks = slices.Clone(commands)
+}
+
+// OpenFile implements Backend.
+func (p *portal) OpenFile(ctx context.Context, opts *Options) (*Selection, error) {
+	return p.open(ctx, withMode(opts, false, false))
+}
+
+// OpenFiles implements Backend.
+func (p *portal) OpenFiles(ctx context.Context, opts *Options) (*Selection, error) {
+	return p.open(ctx, withMode(opts, true, false))
+}
+
+// OpenDirectory implements Backend.
+func (p *portal) OpenDirectory(ctx context.Context, opts *Options) (*Selection, error) {
+	return p.open(ctx, withMode(opts, opts.Multiple, true))
+}
+
+// SaveFile opens a file picker to choose the location of a file to create.
+func (p *portal) SaveFile(ctx context.Context, opts *Options) (*Selection, error) {
+	var selection *Selection
+	err := p.withDesktopPortal(ctx, func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error {
+		// Invoke the SaveFile method.
 		var requestHandle string
-		err := desktopPortal.Call("org.freedesktop.portal.FileChooser.SaveFile", 0, config.parentWindow, "Choose Save Location", map[string]dbus.Variant{
-			"handle_token": dbus.MakeVariant(config.handleToken),
-			"current_name": dbus.MakeVariant(name),
-		}).Store(&requestHandle)
-		if err != nil {
-			return fmt.Errorf("failed to call OpenFile: %w", err)
+		options := makeOptions(config, opts)
+		if opts.Name != "" {
+			options["current_name"] = dbus.MakeVariant(opts.Name)
+		}
+		if opts.File != "" {
+			options["current_file"] = makePath(opts.File)
 		}
 
-		// Make sure we got the request object's path right. Update our subscription otherwise.
-		if requestHandle != config.expectedRequestHandle {
-			if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(dbus.ObjectPath(requestHandle))); err != nil {
-				return fmt.Errorf("failed to subscribe to request: %w", err)
-			}
-			// Reset signal handling.
-			signals := make(chan *dbus.Signal, 1)
-			conn.Signal(signals)
-			config.signals = signals
+		err := desktopPortal.Call("org.freedesktop.portal.FileChooser.SaveFile", 0, config.parentWindow, title(opts, "Choose Save Location"), options).Store(&requestHandle)
+		if err != nil {
+			return fmt.Errorf("failed to call SaveFile: %w", portalError(err))
 		}
 
 		// Wait for the response from the file dialog.
-		response := <-config.signals
-		uris := extractURIsFromSignal(response)
-
-		// Error if no files were selected.
-		if len(uris) < 1 {
-			return ErrUserDecline
+		response, err := config.wait(ctx, conn, requestHandle)
+		if err != nil {
+			return err
 		}
 
-		// Remove the protocol from the URI.
-		parsedURL, err := url.Parse(uris[0])
+		selection, err = extractSelectionFromSignal(response, opts)
 		if err != nil {
-			return fmt.Errorf("failed parsing file path %s: %w", uris[0], err)
+			return err
 		}
 
-		filename = parsedURL.Path
+		selection.Writable = true
 		return nil
 	})
+	if errors.Is(err, errNoDesktopPortal) {
+		return p.withFallback(func(backend Backend) (*Selection, error) {
+			return backend.SaveFile(ctx, opts)
+		})
+	}
+	return selection, err
 }
 
-//
-//
-//
-//
+// SaveFiles opens a directory picker to choose where the files with the given names are created.
+func (p *portal) SaveFiles(ctx context.Context, opts *Options, names []string) (*Selection, error) {
+	var selection *Selection
+	err := p.withDesktopPortal(ctx, func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error {
+		// Invoke the SaveFiles method.
+		var requestHandle string
+		options := makeOptions(config, opts)
 
-type configOpen struct {
-	label      string
-	extensions []string
-	multi      bool
-	dir        bool
-}
+		files := make([][]byte, len(names))
+		for i, name := range names {
+			files[i] = append([]byte(name), 0)
+		}
+		options["files"] = dbus.MakeVariant(files)
 
-// importFile opens a file picker to choose a file.
-func (e *Explorer) importFile(extensions ...string) (string, error) {
-	vs, err := e.open(configOpen{
-		label:      "Choose File",
-		extensions: extensions,
-	})
-	if err != nil {
-		return "", err
-	}
+		err := desktopPortal.Call("org.freedesktop.portal.FileChooser.SaveFiles", 0, config.parentWindow, title(opts, "Choose Save Location"), options).Store(&requestHandle)
+		if err != nil {
+			return fmt.Errorf("failed to call SaveFiles: %w", portalError(err))
+		}
 
-	return vs[0], nil
-}
+		// Wait for the response from the file dialog.
+		response, err := config.wait(ctx, conn, requestHandle)
+		if err != nil {
+			return err
+		}
+
+		selection, err = extractSelectionFromSignal(response, opts)
+		if err != nil {
+			return err
+		}
 
-// importFiles opens a multi-file picker to choose multiple files.
-func (e *Explorer) importFiles(extensions ...string) ([]string, error) {
-	return e.open(configOpen{
-		label:      "Choose Files",
-		extensions: extensions,
-		multi:      true,
+		selection.Writable = true
+		return nil
 	})
+	if errors.Is(err, errNoDesktopPortal) {
+		// The fallbacks can't create several files at once.
+		return p.withFallback(func(backend Backend) (*Selection, error) {
+			return saveFilesInDirectory(ctx, backend, opts, names)
+		})
+	}
+	return selection, err
 }
 
-func (e *Explorer) open(cfg configOpen) ([]string, error) {
-	var filenames []string
-	return filenames, e.withDesktopPortal(func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error {
+//
+//
+//
+//
+
+// open opens a file picker to choose files or directories.
+func (p *portal) open(ctx context.Context, opts *Options) (*Selection, error) {
+	label := openLabel(opts)
+
+	var selection *Selection
+	err := p.withDesktopPortal(ctx, func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error {
 		// Invoke the OpenFile method.
 		var requestHandle string
-		options := map[string]dbus.Variant{
-			"handle_token": dbus.MakeVariant(config.handleToken),
-			"multiple":     dbus.MakeVariant(cfg.multi),
-			"directory":    dbus.MakeVariant(cfg.dir),
-		}
+		options := makeOptions(config, opts)
+		options["multiple"] = dbus.MakeVariant(opts.Multiple)
+		options["directory"] = dbus.MakeVariant(opts.Directory)
 
-		if len(cfg.extensions) > 0 {
-			options["filters"] = makeFilter(cfg.extensions)
+		// The older versions ignore the directory option and select files.
+		if version := fileChooserVersion(desktopPortal); opts.Directory && version > 0 && version < 3 {
+			return fmt.Errorf("%w: selecting directories requires the version 3 of the FileChooser portal", ErrNotAvailable)
 		}
 
-		err := desktopPortal.Call("org.freedesktop.portal.FileChooser.OpenFile", 0, config.parentWindow, cfg.label, options).Store(&requestHandle)
+		err := desktopPortal.Call("org.freedesktop.portal.FileChooser.OpenFile", 0, config.parentWindow, title(opts, label), options).Store(&requestHandle)
 		if err != nil {
-			return fmt.Errorf("failed to call OpenFile: %w", err)
+			return fmt.Errorf("failed to call OpenFile: %w", portalError(err))
 		}
 
-		// Make sure we got the request object's path right. Update our subscription otherwise.
-		if requestHandle != config.expectedRequestHandle {
-			if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(dbus.ObjectPath(requestHandle))); err != nil {
-				return fmt.Errorf("failed to subscribe to request: %w", err)
-			}
-			// Reset signal handling.
-			signals := make(chan *dbus.Signal, 1)
-			conn.Signal(signals)
-			config.signals = signals
+		// Wait for the response from the file dialog.
+		response, err := config.wait(ctx, conn, requestHandle)
+		if err != nil {
+			return err
 		}
 
-		// Wait for the response from the file dialog.
-		response := <-config.signals
-		uris := extractURIsFromSignal(response)
+		selection, err = extractSelectionFromSignal(response, opts)
+		return err
+	})
+	if errors.Is(err, errNoDesktopPortal) {
+		return p.withFallback(func(backend Backend) (*Selection, error) {
+			return open(ctx, backend, opts)
+		})
+	}
+	return selection, err
+}
+
+// makeOptions constructs the options shared by the FileChooser methods.
+func makeOptions(config config, opts *Options) map[string]dbus.Variant {
+	options := map[string]dbus.Variant{
+		"handle_token": dbus.MakeVariant(config.handleToken),
+		"modal":        dbus.MakeVariant(!opts.Modeless),
+	}
 
-		// Error if no files were selected.
-		if len(uris) < 1 {
-			return ErrUserDecline
+	if opts.AcceptLabel != "" {
+		options["accept_label"] = dbus.MakeVariant(opts.AcceptLabel)
+	}
+	if opts.Folder != "" {
+		options["current_folder"] = makePath(opts.Folder)
+	}
+
+	filters := opts.filters()
+	if len(filters) > 0 {
+		options["filters"] = makeFilters(filters)
+	}
+	if opts.CurrentFilter != nil {
+		filter := *opts.CurrentFilter
+		if i := opts.currentFilter(filters); i >= 0 {
+			filter = filters[i]
 		}
+		options["current_filter"] = makeCurrentFilter(filter)
+	}
+
+	if len(opts.Choices) > 0 {
+		options["choices"] = makeChoices(opts.Choices)
+	}
+
+	return options
+}
+
+// makePath encodes the given path as a NUL-terminated byte array dbus variant.
+func makePath(path string) dbus.Variant {
+	return dbus.MakeVariant(append([]byte(path), 0))
+}
 
-		filenames = make([]string, len(uris))
-		for i, uri := range uris {
-			// Remove the protocol from the URI.
-			parsedURL, err := url.Parse(uri)
-			if err != nil {
-				return fmt.Errorf("failed parsing file path %s: %w", uri, err)
+// portalFilter is the dbus form of a Filter.
+type portalFilter struct {
+	// Field names _must_ be exported so that they are available via reflection,
+	// otherwise they will not be sent.
+	Name  string
+	Rules []portalFilterRule
+}
+
+// portalFilterRule is either a glob pattern (Kind 0) or a mime type (Kind 1).
+type portalFilterRule struct {
+	Kind    uint32
+	Pattern string
+}
+
+func newPortalFilter(filter Filter) portalFilter {
+	pf := portalFilter{
+		Name:  filter.Name,
+		Rules: make([]portalFilterRule, 0, len(filter.Patterns)+len(filter.MIMETypes)),
+	}
+	for _, pattern := range filter.Patterns {
+		pf.Rules = append(pf.Rules, portalFilterRule{Kind: 0, Pattern: caseInsensitiveGlob(pattern)})
+	}
+	for _, mt := range filter.MIMETypes {
+		pf.Rules = append(pf.Rules, portalFilterRule{Kind: 1, Pattern: mt})
+	}
+	return pf
+}
+
+// caseInsensitiveGlob returns the given glob pattern matching both cases of its letters
+// (such as `*.[jJ][pP][gG]`), the dialogs matching the patterns case-sensitively.
+func caseInsensitiveGlob(pattern string) string {
+	var b strings.Builder
+	inClass := false
+	for _, r := range pattern {
+		lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+		switch {
+		case inClass:
+			if r == ']' {
+				inClass = false
 			}
-			filenames[i] = parsedURL.Path
+			b.WriteRune(r)
+		case r == '[':
+			inClass = true
+			b.WriteRune(r)
+		case lower != upper:
+			b.WriteString("[" + string(lower) + string(upper) + "]")
+		default:
+			b.WriteRune(r)
 		}
+	}
+	return b.String()
+}
 
-		return nil
-	})
+// makeFilters constructs the file type filters and encodes them as a dbus variant.
+func makeFilters(filters []Filter) dbus.Variant {
+	pfs := make([]portalFilter, len(filters))
+	for i, filter := range filters {
+		pfs[i] = newPortalFilter(filter)
+	}
+
+	return dbus.MakeVariantWithSignature(pfs, dbus.ParseSignatureMust("a(sa(us))"))
 }
 
-// makeFilter constructs a file type filter appropriate for the provided extensions
-// and encodes it as a dbus variant.
-func makeFilter(extensions []string) dbus.Variant {
-	// Resolve the provided extensions to their corresponding mime types.
-	type mimetype struct {
-		// Field names _must_ be exported so that they are available via reflection,
-		// otherwise they will not be sent.
-		Kind uint
-		Name string
-	}
-	mimes := make([]mimetype, len(extensions))
-	for i := range extensions {
-		ext := extensions[i]
-		if !strings.HasPrefix(ext, ".") {
-			ext = "." + ext
+// makeCurrentFilter encodes the given filter as a dbus variant.
+func makeCurrentFilter(filter Filter) dbus.Variant {
+	return dbus.MakeVariantWithSignature(newPortalFilter(filter), dbus.ParseSignatureMust("(sa(us))"))
+}
+
+// portalChoice is the dbus form of a Choice.
+type portalChoice struct {
+	// Field names _must_ be exported so that they are available via reflection,
+	// otherwise they will not be sent.
+	ID      string
+	Label   string
+	Options []portalChoiceOption
+	Default string
+}
+
+type portalChoiceOption struct {
+	ID    string
+	Label string
+}
+
+// makeChoices constructs the choices and encodes them as a dbus variant.
+func makeChoices(choices []Choice) dbus.Variant {
+	pcs := make([]portalChoice, len(choices))
+	for i, choice := range choices {
+		pcs[i] = portalChoice{
+			ID:      choice.ID,
+			Label:   choice.Label,
+			Options: make([]portalChoiceOption, len(choice.Options)),
+			Default: choice.defaultValue(),
 		}
-		mt := mime.TypeByExtension(ext)
-		if mt != "" {
-			mimes[i] = mimetype{
-				Kind: 1,
-				Name: mt,
-			}
-		} else {
-			mimes[i] = mimetype{
-				Kind: 0,
-				Name: "*" + ext,
-			}
+		for j, option := range choice.Options {
+			pcs[i].Options[j] = portalChoiceOption(option)
 		}
 	}
 
-	// Transform the filter into its dbus variant form.
-	filter := []struct {
-		// Field names must be exported so they are available via reflection, otherwise
-		// they will not be sent.
-		Name  string
-		Value []mimetype
-	}{
-		{
-			Name:  "Filter",
-			Value: mimes,
-		},
+	return dbus.MakeVariantWithSignature(pcs, dbus.ParseSignatureMust("a(ssa(ss)s)"))
+}
+
+// matchFilter returns the filter having the same name as the given one, or the given one if not found.
+func matchFilter(filters []Filter, filter Filter) *Filter {
+	for i := range filters {
+		if filters[i].Name == filter.Name {
+			return &filters[i]
+		}
 	}
-	return dbus.MakeVariantWithSignature(filter, dbus.ParseSignatureMust("a(sa(us))"))
+	return &filter
 }
 
 //
@@ -212,6 +423,106 @@ func makeFilter(extensions []string) dbus.Variant {
 //
 //
 
+// extractSelectionFromSignal converts the results within the body of the signal to a Selection.
+// It returns ErrUserDecline when no files were selected.
+func extractSelectionFromSignal(sig *dbus.Signal, opts *Options) (*Selection, error) {
+	if err := extractErrorFromSignal(sig); err != nil {
+		return nil, err
+	}
+
+	uris := extractURIsFromSignal(sig)
+
+	// Error if no files were selected.
+	if len(uris) < 1 {
+		return nil, ErrUserDecline
+	}
+
+	selection := &Selection{
+		Paths:   make([]string, len(uris)),
+		URIs:    uris,
+		Choices: completeChoices(extractChoicesFromSignal(sig), opts.Choices),
+	}
+
+	for i, uri := range uris {
+		// Remove the protocol from the URI.
+		parsedURL, err := url.Parse(uri)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing file path %s: %w", uri, err)
+		}
+		selection.Paths[i] = parsedURL.Path
+	}
+
+	if filter, ok := extractCurrentFilterFromSignal(sig); ok {
+		selection.Filter = matchFilter(opts.filters(), filter)
+	}
+
+	if results := extractResultsFromSignal(sig); results != nil {
+		selection.Writable, _ = results["writable"].Value().(bool)
+	}
+
+	return selection, nil
+}
+
+// extractErrorFromSignal converts the response code within the body of the signal to an error,
+// nil on success.
+func extractErrorFromSignal(sig *dbus.Signal) error {
+	switch code := extractResponseCodeFromSignal(sig); code {
+	case 0: // Success.
+		return nil
+	case 1:
+		return ErrUserDecline
+	default:
+		return &PortalError{Code: code}
+	}
+}
+
+// extractResponseCodeFromSignal locates the response code within the body of the signal.
+// It's 0 on success, 1 when the user cancelled and 2 when the interaction ended in some other way.
+func extractResponseCodeFromSignal(sig *dbus.Signal) uint32 {
+	if len(sig.Body) == 0 {
+		return 2
+	}
+
+	code, ok := sig.Body[0].(uint32)
+	if !ok {
+		return 2
+	}
+	return code
+}
+
+// extractResultsFromSignal locates the results within the body of the signal.
+func extractResultsFromSignal(sig *dbus.Signal) map[string]dbus.Variant {
+	for _, element := range sig.Body {
+		if asMap, ok := element.(map[string]dbus.Variant); ok {
+			return asMap
+		}
+	}
+
+	return nil
+}
+
+// extractChoicesFromSignal locates the values of the choices within the body of the signal.
+func extractChoicesFromSignal(sig *dbus.Signal) map[string]string {
+	variant, ok := extractResultsFromSignal(sig)["choices"]
+	if !ok {
+		return nil
+	}
+
+	var choices []struct {
+		ID    string
+		Value string
+	}
+	if err := variant.Store(&choices); err != nil {
+		return nil
+	}
+
+	values := make(map[string]string, len(choices))
+	for _, choice := range choices {
+		values[choice.ID] = choice.Value
+	}
+	return values
+}
+
 // extractURIsFromSignal locates the list of file URIs within the body of the
 // signal and converts them to a slice of strings. If there were no URIs or
 // if they are not a slice of strings, it returns the empty slice.
@@ -235,6 +546,46 @@ func extractURIsFromSignal(sig *dbus.Signal) []string {
 	return uris
 }
 
+// extractCurrentFilterFromSignal locates the filter selected by the user
+// within the body of the signal.
+func extractCurrentFilterFromSignal(sig *dbus.Signal) (Filter, bool) {
+	variant, ok := extractResultsFromSignal(sig)["current_filter"]
+	if !ok {
+		return Filter{}, false
+	}
+
+	var pf portalFilter
+	if err := variant.Store(&pf); err != nil {
+		return Filter{}, false
+	}
+
+	filter := Filter{Name: pf.Name}
+	for _, rule := range pf.Rules {
+		switch rule.Kind {
+		case 0:
+			filter.Patterns = append(filter.Patterns, rule.Pattern)
+		case 1:
+			filter.MIMETypes = append(filter.MIMETypes, rule.Pattern)
+		}
+	}
+	return filter, true
+}
+
+// portalError converts the given D-Bus error to a PortalError.
+func portalError(err error) error {
+	var derr dbus.Error
+	if errors.As(err, &derr) {
+		return &PortalError{Name: derr.Name, Err: err}
+	}
+
+	var pderr *dbus.Error
+	if errors.As(err, &pderr) {
+		return &PortalError{Name: pderr.Name, Err: err}
+	}
+
+	return err
+}
+
 // randString generates a string of the form prefix+hexnumber, where hexnumber
 // is the hex-encoded form of 16 bytes of cryptographically random data.
 func randString(prefix string) (string, error) {
@@ -265,28 +616,184 @@ type config struct {
 	signals               chan *dbus.Signal
 }
 
+// wait waits for the response of the given request.
+// When the context is done before, the request is closed, which dismisses the dialog,
+// and the context's error is returned.
+func (c config) wait(ctx context.Context, conn *dbus.Conn, requestHandle string) (*dbus.Signal, error) {
+	// Make sure we got the request object's path right. Update our subscription otherwise.
+	if requestHandle != c.expectedRequestHandle {
+		if err := conn.AddMatchSignal(matchResponse(requestHandle)...); err != nil {
+			return nil, fmt.Errorf("failed to subscribe to request: %w", err)
+		}
+		defer conn.RemoveMatchSignal(matchResponse(requestHandle)...)
+	}
+
+	for {
+		select {
+		case response, ok := <-c.signals:
+			if !ok {
+				return nil, fmt.Errorf("%w: session bus connection closed", ErrDialogFailed)
+			}
+
+			// The channel receives all the signals of the connection (e.g. NameAcquired),
+			// so only the response of our request is considered.
+			if response.Path != dbus.ObjectPath(requestHandle) || response.Name != "org.freedesktop.portal.Request.Response" {
+				continue
+			}
+			return response, nil
+		case <-ctx.Done():
+			request := conn.Object("org.freedesktop.portal.Desktop", dbus.ObjectPath(requestHandle))
+			if err := request.Call("org.freedesktop.portal.Request.Close", 0).Err; err != nil {
+				return nil, errors.Join(ctx.Err(), fmt.Errorf("failed to close request: %w", err))
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// matchResponse returns the match options of the Response signal of the given request.
+func matchResponse(requestHandle string) []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchObjectPath(dbus.ObjectPath(requestHandle)),
+		dbus.WithMatchInterface("org.freedesktop.portal.Request"),
+		dbus.WithMatchMember("Response"),
+	}
+}
+
+// serviceAvailable reports whether the given name is owned on the bus or can be activated,
+// such as the desktop portal.
+func serviceAvailable(conn *dbus.Conn, name string) bool {
+	bus := conn.BusObject()
+
+	var owned bool
+	if err := bus.Call("org.freedesktop.DBus.NameHasOwner", 0, name).Store(&owned); err == nil && owned {
+		return true
+	}
+
+	var names []string
+	if err := bus.Call("org.freedesktop.DBus.ListActivatableNames", 0).Store(&names); err != nil {
+		return false
+	}
+	return slices.Contains(names, name)
+}
+
+// parentWindow returns the identifier of the window the dialogs are attached to,
+// and the function releasing it once the dialog is closed.
+func (p *portal) parentWindow() (string, func()) {
+	p.mutex.Lock()
+	x11Window, waylandDisplay, waylandSurface := p.X11Window, p.WaylandDisplay, p.WaylandSurface
+	p.mutex.Unlock()
+
+	if waylandDisplay != nil && waylandSurface != nil {
+		handle, unexport, err := exportWaylandSurface(waylandDisplay, waylandSurface)
+		if err == nil {
+			return "wayland:" + handle, unexport
+		}
+		// The dialog is shown without parent.
+	}
+
+	if x11Window != 0 {
+		return "x11:" + fmt.Sprintf("%x", x11Window), func() {}
+	}
+
+	return "", func() {}
+}
+
+// desktopPortal connects to the session bus and returns the object implementing the desktop portal.
+// The connection is kept for the next dialogs.
+func (p *portal) desktopPortal() (*dbus.Conn, dbus.BusObject, error) {
+	conn, err := p.connection()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unable to connect to session bus: %w", errNoDesktopPortal, err)
+	}
+
+	if !serviceAvailable(conn, "org.freedesktop.portal.Desktop") {
+		return nil, nil, errNoDesktopPortal
+	}
+
+	return conn, conn.Object("org.freedesktop.portal.Desktop", "/org/freedesktop/portal/desktop"), nil
+}
+
+// fileChooserVersion returns the version of the FileChooser portal, 0 when unknown.
+// Some options are ignored by the older versions.
+func fileChooserVersion(desktopPortal dbus.BusObject) uint32 {
+	return portalVersion(desktopPortal, "org.freedesktop.portal.FileChooser")
+}
+
+// portalVersion returns the version of the given portal interface, 0 when unknown.
+func portalVersion(desktopPortal dbus.BusObject, iface string) uint32 {
+	variant, err := desktopPortal.GetProperty(iface + ".version")
+	if err != nil {
+		return 0
+	}
+
+	version, _ := variant.Value().(uint32)
+	return version
+}
+
+// Capabilities implements CapabilitiesReporter.
+// They depend on the version of the FileChooser portal, or on the fallback used when there isn't one.
+func (p *portal) Capabilities() (Capabilities, error) {
+	_, obj, err := p.desktopPortal()
+	if errors.Is(err, errNoDesktopPortal) {
+		backend, err := p.fallback()
+		if err != nil {
+			return Capabilities{}, err
+		}
+
+		capabilities, err := backend.(CapabilitiesReporter).Capabilities()
+		if err != nil {
+			return Capabilities{}, err
+		}
+		capabilities.RevealFile = p.fileManagerAvailable()
+		capabilities.OpenWith = xdgOpenAvailable()
+		return capabilities, nil
+	}
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	version := fileChooserVersion(obj)
+	known := version > 0
+	return Capabilities{
+		RevealFile:      openDirectorySupported(obj) || p.fileManagerAvailable(),
+		OpenWith:        openFileSupported(obj) || xdgOpenAvailable(),
+		OpenWithChooser: openFileSupported(obj) && askSupported(obj),
+		OpenFile:        true,
+		OpenFiles:       true,
+		SaveFile:        true,
+		SaveFiles:       !known || version >= 2,
+		OpenDirectory:   !known || version >= 3,
+		OpenDirectories: !known || version >= 3,
+		Filters:         true,
+		FilterSelection: true,
+		Choices:         true,
+		Folder:          !known || version >= 4,
+		AcceptLabel:     true,
+		Modal:           true,
+	}, nil
+}
+
 // withDesktopPortal connects to the session dbus and finds the service
 // implementing the freedesktop.org portals. It accepts a function that
 // it will run with access to the connection, portal, and a set of
 // parameters that are useful for making requests against the portal.
-func (e *Explorer) withDesktopPortal(work func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error) error {
-	// Connect to the session bus.
-	conn, err := dbus.ConnectSessionBus()
+func (p *portal) withDesktopPortal(ctx context.Context, work func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	conn, obj, err := p.desktopPortal()
 	if err != nil {
-		return fmt.Errorf("unable to connect to session bus: %w", err)
+		return err
 	}
-	defer conn.Close()
 
 	// Figure out our own connection name.
 	senderName := sanitizeSenderName(conn.Names()[0])
 
 	// Determine parameters for the methods we will call.
-	obj := conn.Object("org.freedesktop.portal.Desktop", "/org/freedesktop/portal/desktop")
-
-	var parentWindow string
-	if e.X11Window != 0 {
-		parentWindow = "x11:" + fmt.Sprintf("%x", e.X11Window)
-	}
+	parentWindow, release := p.parentWindow()
+	defer release()
 
 	handle, err := randString("gexplorer")
 	if err != nil {
@@ -298,13 +805,15 @@ func (e *Explorer) withDesktopPortal(work func(conn *dbus.Conn, desktopPortal db
 
 	// Subscribe to signals on the request object's path before submitting the request to avoid
 	// race conditions.
-	if err := conn.AddMatchSignal(dbus.WithMatchObjectPath(dbus.ObjectPath(expectedRequestHandle))); err != nil {
+	if err := conn.AddMatchSignal(matchResponse(expectedRequestHandle)...); err != nil {
 		return fmt.Errorf("failed to subscribe to request: %w", err)
 	}
+	defer conn.RemoveMatchSignal(matchResponse(expectedRequestHandle)...)
 
 	// Prepare for signal handling.
-	signals := make(chan *dbus.Signal, 1)
+	signals := make(chan *dbus.Signal, 8)
 	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
 
 	// Perform some work while connected.
 	return work(conn, obj, config{
@@ -0,0 +1,295 @@
+//go:build linux && !android
+// +build linux,!android
+
+package gexplorer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// defaultFallbacks are the command-line dialogs used when no desktop portal is available,
+// by order of preference. The terminal is used as last resort, such as over SSH.
+var defaultFallbacks = []string{"zenity", "kdialog", "yad", "terminal"}
+
+// commandArguments builds the arguments of a command-line dialog.
+// The command must print the selected paths on separated lines.
+type commandArguments func(opts *Options, title string, save bool) ([]string, error)
+
+// commands holds the supported command-line dialogs, by executable name.
+var commands = map[string]commandArguments{
+	"zenity":  zenityArguments,
+	"kdialog": kdialogArguments,
+	"yad":     yadArguments,
+}
+
+func init() {
+	for name := range commands {
+		RegisterBackend(name, func(_ RunHandler) Backend {
+			return &command{name: name}
+		})
+	}
+}
+
+// command opens file explorers by running a command-line dialog (such as zenity).
+type command struct {
+	// name is the name of the executable, or its path.
+	name string
+}
+
+// OpenFile implements Backend.
+func (c *command) OpenFile(ctx context.Context, opts *Options) (*Selection, error) {
+	return c.open(ctx, withMode(opts, false, false))
+}
+
+// OpenFiles implements Backend.
+func (c *command) OpenFiles(ctx context.Context, opts *Options) (*Selection, error) {
+	return c.open(ctx, withMode(opts, true, false))
+}
+
+// OpenDirectory implements Backend.
+func (c *command) OpenDirectory(ctx context.Context, opts *Options) (*Selection, error) {
+	return c.open(ctx, withMode(opts, opts.Multiple, true))
+}
+
+// SaveFile implements Backend.
+func (c *command) SaveFile(ctx context.Context, opts *Options) (*Selection, error) {
+	return c.run(ctx, opts, title(opts, "Choose Save Location"), true)
+}
+
+// Capabilities implements CapabilitiesReporter.
+func (c *command) Capabilities() (Capabilities, error) {
+	if _, ok := commands[filepath.Base(c.name)]; !ok {
+		return Capabilities{}, fmt.Errorf("%w: unsupported file dialog command %s", ErrNotAvailable, c.name)
+	}
+	if _, err := exec.LookPath(c.name); err != nil {
+		return Capabilities{}, fmt.Errorf("%w: %w", ErrNotAvailable, err)
+	}
+
+	return Capabilities{
+		OpenFile:        true,
+		OpenFiles:       true,
+		SaveFile:        true,
+		SaveFiles:       true,
+		OpenDirectory:   true,
+		OpenDirectories: filepath.Base(c.name) != "kdialog",
+		Filters:         true,
+		Folder:          true,
+	}, nil
+}
+
+func (c *command) open(ctx context.Context, opts *Options) (*Selection, error) {
+	return c.run(ctx, opts, title(opts, openLabel(opts)), false)
+}
+
+// run runs the command-line dialog and parses the selected paths from its output.
+func (c *command) run(ctx context.Context, opts *Options, title string, save bool) (*Selection, error) {
+	arguments, ok := commands[filepath.Base(c.name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported file dialog command %s", ErrNotAvailable, c.name)
+	}
+
+	path, err := exec.LookPath(c.name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotAvailable, err)
+	}
+
+	args, err := arguments(opts, title, save)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := exec.CommandContext(ctx, path, args...).Output()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil, ErrUserDecline
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrDialogFailed, filepath.Base(c.name), err)
+	}
+
+	var paths []string
+	for _, path := range strings.Split(string(output), "\n") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	// Error if no files were selected.
+	if len(paths) < 1 {
+		return nil, ErrUserDecline
+	}
+
+	selection := NewSelection(paths)
+	selection.Choices = completeChoices(nil, opts.Choices)
+	selection.Writable = true
+	return selection, nil
+}
+
+// withFallback runs the given dialog using the first fallback usable on the system.
+func (p *portal) withFallback(dialog func(backend Backend) (*Selection, error)) (*Selection, error) {
+	backend, err := p.fallback()
+	if err != nil {
+		return nil, err
+	}
+	return dialog(backend)
+}
+
+// fallback returns the first command-line dialog of the fallback chain usable on the system.
+// The graphical ones need a display.
+func (p *portal) fallback() (Backend, error) {
+	display := os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+
+	p.mutex.Lock()
+	fallbacks := p.fallbacks
+	p.mutex.Unlock()
+
+	for _, name := range fallbacks {
+		if name == "terminal" {
+			if stdinIsTerminal() {
+				return stdinTerminal(), nil
+			}
+			continue
+		}
+
+		if _, ok := commands[filepath.Base(name)]; !ok || !display {
+			continue
+		}
+
+		if _, err := exec.LookPath(name); err != nil {
+			continue
+		}
+
+		return &command{name: name}, nil
+	}
+
+	return nil, fmt.Errorf("%w: no desktop portal nor file dialog command found", ErrNotAvailable)
+}
+
+// zenityArguments builds the arguments of zenity.
+// https://help.gnome.org/users/zenity/stable/file-selection.html
+func zenityArguments(opts *Options, title string, save bool) ([]string, error) {
+	return append([]string{"--file-selection"}, gtkArguments(opts, title, save)...), nil
+}
+
+// yadArguments builds the arguments of yad, which are mostly the same as zenity.
+func yadArguments(opts *Options, title string, save bool) ([]string, error) {
+	return append([]string{"--file"}, gtkArguments(opts, title, save)...), nil
+}
+
+// gtkArguments builds the arguments shared by zenity and yad.
+func gtkArguments(opts *Options, title string, save bool) []string {
+	args := []string{"--separator=\n", "--title=" + title}
+
+	if save {
+		args = append(args, "--save", "--confirm-overwrite")
+	}
+	if opts.Multiple && !save {
+		args = append(args, "--multiple")
+	}
+	if opts.Directory && !save {
+		args = append(args, "--directory")
+	}
+	if path := startPath(opts, save); path != "" {
+		args = append(args, "--filename="+path)
+	}
+
+	for _, filter := range commandFilters(opts) {
+		args = append(args, "--file-filter="+filter.Name+" | "+strings.Join(filter.Patterns, " "))
+	}
+
+	return args
+}
+
+// kdialogArguments builds the arguments of kdialog.
+// https://develop.kde.org/docs/administration/kdialog/
+func kdialogArguments(opts *Options, title string, save bool) ([]string, error) {
+	args := []string{"--title", title}
+
+	switch {
+	case save:
+		args = append(args, "--getsavefilename")
+	case opts.Directory && opts.Multiple:
+		return nil, fmt.Errorf("%w: kdialog can't select multiple directories", ErrNotAvailable)
+	case opts.Directory:
+		args = append(args, "--getexistingdirectory")
+	case opts.Multiple:
+		args = append(args, "--multiple", "--separate-output", "--getopenfilename")
+	default:
+		args = append(args, "--getopenfilename")
+	}
+
+	path := startPath(opts, save)
+	if path == "" {
+		path = "."
+	}
+	args = append(args, path)
+
+	if opts.Directory && !save {
+		return args, nil
+	}
+
+	var filters []string
+	for _, filter := range commandFilters(opts) {
+		filters = append(filters, strings.Join(filter.Patterns, " ")+"|"+filter.Name)
+	}
+	if len(filters) > 0 {
+		args = append(args, strings.Join(filters, "\n"))
+	}
+
+	return args, nil
+}
+
+// startPath returns the path where the command-line dialog is opened.
+// Directories have a trailing slash, so the dialogs don't consider them as a file name.
+func startPath(opts *Options, save bool) string {
+	if save {
+		if opts.File != "" {
+			return opts.File
+		}
+		if opts.Name != "" {
+			return filepath.Join(opts.Folder, opts.Name)
+		}
+	}
+
+	if opts.Folder != "" {
+		return strings.TrimSuffix(opts.Folder, "/") + "/"
+	}
+	return ""
+}
+
+// commandFilters returns the filters of the options using only glob patterns,
+// the command-line dialogs don't support MIME types.
+// The patterns match both cases, as the dialogs match them case-sensitively.
+// The current filter is put first as it's the one selected when the dialog is opened.
+func commandFilters(opts *Options) []Filter {
+	filters := opts.filters()
+	if i := opts.currentFilter(filters); i > 0 {
+		filters = append([]Filter{filters[i]}, slices.Delete(slices.Clone(filters), i, i+1)...)
+	}
+
+	var patterns []Filter
+	for _, filter := range filters {
+		globs := filter.globs()
+		if len(globs) == 0 {
+			continue
+		}
+
+		for i, glob := range globs {
+			globs[i] = caseInsensitiveGlob(glob)
+		}
+		patterns = append(patterns, Filter{Name: filter.Name, Patterns: globs})
+	}
+
+	return patterns
+}
@@ -0,0 +1,16 @@
+//go:build linux && !android && (!cgo || nowayland)
+// +build linux
+// +build !android
+// +build !cgo nowayland
+
+package gexplorer
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// exportWaylandSurface requires cgo, so the dialogs don't have a parent on Wayland.
+func exportWaylandSurface(_, _ unsafe.Pointer) (string, func(), error) {
+	return "", nil, errors.New("wayland support is disabled")
+}
@@ -0,0 +1,166 @@
+//go:build linux && !android
+// +build linux,!android
+
+package gexplorer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/godbus/dbus/v5"
+)
+
+//
+// https://flatpak.github.io/xdg-desktop-portal/docs/doc-org.freedesktop.portal.OpenURI.html
+//
+
+// RevealFile implements FileRevealer.
+// Without desktop portal, the file manager is asked directly using the org.freedesktop.FileManager1 interface.
+func (p *portal) RevealFile(ctx context.Context, path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	err = p.openURI(ctx, "OpenDirectory", path, nil, openDirectorySupported)
+	if errors.Is(err, errNoDesktopPortal) {
+		return p.showItems(ctx, path)
+	}
+	return err
+}
+
+// OpenWith implements FileOpener.
+// Without desktop portal, the file is opened using xdg-open, which can't ask the user.
+func (p *portal) OpenWith(ctx context.Context, path string, ask bool) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	options := map[string]dbus.Variant{
+		"ask": dbus.MakeVariant(ask),
+	}
+
+	err = p.openURI(ctx, "OpenFile", path, options, openFileSupported)
+	if errors.Is(err, errNoDesktopPortal) {
+		return xdgOpen(ctx, path, ask)
+	}
+	return err
+}
+
+// openURI calls the given method of the OpenURI portal for the given file, and waits for its response.
+// errNoDesktopPortal is returned when the portal doesn't implement the method.
+func (p *portal) openURI(ctx context.Context, method, path string, options map[string]dbus.Variant, supported func(dbus.BusObject) bool) error {
+	// The portal only accepts file descriptors, so the file is opened even in a sandbox.
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return p.withDesktopPortal(ctx, func(conn *dbus.Conn, desktopPortal dbus.BusObject, config config) error {
+		if !supported(desktopPortal) {
+			return errNoDesktopPortal
+		}
+
+		// Invoke the method.
+		var requestHandle string
+		if options == nil {
+			options = map[string]dbus.Variant{}
+		}
+		options["handle_token"] = dbus.MakeVariant(config.handleToken)
+
+		err := desktopPortal.Call("org.freedesktop.portal.OpenURI."+method, 0, config.parentWindow, dbus.UnixFD(file.Fd()), options).Store(&requestHandle)
+		if err != nil {
+			return fmt.Errorf("failed to call %s: %w", method, portalError(err))
+		}
+
+		// Wait for the response, the application is launched once done.
+		response, err := config.wait(ctx, conn, requestHandle)
+		if err != nil {
+			return err
+		}
+
+		return extractErrorFromSignal(response)
+	})
+}
+
+// showItems shows the given file in the file manager, using the org.freedesktop.FileManager1 interface.
+// https://www.freedesktop.org/wiki/Specifications/file-manager-interface/
+func (p *portal) showItems(ctx context.Context, path string) error {
+	conn, err := p.connection()
+	if err != nil {
+		return fmt.Errorf("%w: unable to connect to session bus: %w", ErrNotAvailable, err)
+	}
+
+	if !serviceAvailable(conn, "org.freedesktop.FileManager1") {
+		return fmt.Errorf("%w: no desktop portal nor file manager found", ErrNotAvailable)
+	}
+
+	uris := NewSelection([]string{path}).URIs
+	fileManager := conn.Object("org.freedesktop.FileManager1", "/org/freedesktop/FileManager1")
+	if err := fileManager.CallWithContext(ctx, "org.freedesktop.FileManager1.ShowItems", 0, uris, "").Err; err != nil {
+		return fmt.Errorf("failed to call ShowItems: %w", portalError(err))
+	}
+	return nil
+}
+
+// xdgOpen opens the given file with its default application using xdg-open.
+func xdgOpen(ctx context.Context, path string, ask bool) error {
+	if ask {
+		return fmt.Errorf("%w: choosing the application requires a desktop portal", ErrNotAvailable)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	xdgOpen, err := exec.LookPath("xdg-open")
+	if err != nil {
+		return fmt.Errorf("%w: no desktop portal nor xdg-open found", ErrNotAvailable)
+	}
+
+	if err := exec.CommandContext(ctx, xdgOpen, path).Run(); err != nil {
+		return fmt.Errorf("%w: xdg-open: %w", ErrDialogFailed, err)
+	}
+	return nil
+}
+
+// fileManagerAvailable reports whether a file manager implements the org.freedesktop.FileManager1 interface.
+func (p *portal) fileManagerAvailable() bool {
+	conn, err := p.connection()
+	if err != nil {
+		return false
+	}
+	return serviceAvailable(conn, "org.freedesktop.FileManager1")
+}
+
+// xdgOpenAvailable reports whether xdg-open is installed.
+func xdgOpenAvailable() bool {
+	_, err := exec.LookPath("xdg-open")
+	return err == nil
+}
+
+// openFileSupported reports whether the OpenURI portal implements OpenFile,
+// which has been added by its version 2.
+func openFileSupported(desktopPortal dbus.BusObject) bool {
+	version := portalVersion(desktopPortal, "org.freedesktop.portal.OpenURI")
+	return version == 0 || version >= 2
+}
+
+// askSupported reports whether the OpenURI portal supports the ask option,
+// which has been added by its version 3.
+func askSupported(desktopPortal dbus.BusObject) bool {
+	return openDirectorySupported(desktopPortal)
+}
+
+// openDirectorySupported reports whether the OpenURI portal implements OpenDirectory,
+// which has been added by its version 3.
+func openDirectorySupported(desktopPortal dbus.BusObject) bool {
+	version := portalVersion(desktopPortal, "org.freedesktop.portal.OpenURI")
+	return version == 0 || version >= 3
+}
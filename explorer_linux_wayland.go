@@ -0,0 +1,250 @@
+//go:build linux && !android && cgo && !nowayland
+// +build linux,!android,cgo,!nowayland
+
+package gexplorer
+
+/*
+#cgo LDFLAGS: -ldl
+
+#include <dlfcn.h>
+#include <stdint.h>
+#include <stdlib.h>
+#include <string.h>
+
+// libwayland-client is loaded at runtime, so only the used part of its ABI is declared here.
+// The application owning the Wayland display has already loaded it.
+
+struct wl_proxy;
+struct wl_display;
+struct wl_event_queue;
+
+struct wl_message {
+	const char *name;
+	const char *signature;
+	const struct wl_interface **types;
+};
+
+struct wl_interface {
+	const char *name;
+	int version;
+	int method_count;
+	const struct wl_message *methods;
+	int event_count;
+	const struct wl_message *events;
+};
+
+static struct {
+	struct wl_event_queue *(*display_create_queue)(struct wl_display *display);
+	int (*display_roundtrip_queue)(struct wl_display *display, struct wl_event_queue *queue);
+	int (*display_flush)(struct wl_display *display);
+	void (*event_queue_destroy)(struct wl_event_queue *queue);
+	void *(*proxy_create_wrapper)(void *proxy);
+	void (*proxy_wrapper_destroy)(void *wrapper);
+	void (*proxy_set_queue)(struct wl_proxy *proxy, struct wl_event_queue *queue);
+	struct wl_proxy *(*proxy_marshal_constructor)(struct wl_proxy *proxy, uint32_t opcode, const struct wl_interface *interface, ...);
+	struct wl_proxy *(*proxy_marshal_constructor_versioned)(struct wl_proxy *proxy, uint32_t opcode, const struct wl_interface *interface, uint32_t version, ...);
+	void (*proxy_marshal)(struct wl_proxy *proxy, uint32_t opcode, ...);
+	int (*proxy_add_listener)(struct wl_proxy *proxy, void (**implementation)(void), void *data);
+	void (*proxy_destroy)(struct wl_proxy *proxy);
+	const struct wl_interface *registry_interface;
+	const struct wl_interface *surface_interface;
+} wl;
+
+// xdg-foreign-unstable-v2 protocol.
+// https://gitlab.freedesktop.org/wayland/wayland-protocols/-/blob/main/unstable/xdg-foreign/xdg-foreign-unstable-v2.xml
+
+static const struct wl_interface *exported_handle_types[] = { NULL };
+
+static const struct wl_message exported_requests[] = {
+	{ "destroy", "", NULL },
+};
+
+static const struct wl_message exported_events[] = {
+	{ "handle", "s", exported_handle_types },
+};
+
+static const struct wl_interface exported_interface = {
+	"zxdg_exported_v2", 1, 1, exported_requests, 1, exported_events,
+};
+
+// Filled once libwayland-client is loaded, wl_surface_interface being one of its symbols.
+static const struct wl_interface *exporter_export_types[2];
+
+static const struct wl_message exporter_requests[] = {
+	{ "destroy", "", NULL },
+	{ "export_toplevel", "no", exporter_export_types },
+};
+
+static const struct wl_interface exporter_interface = {
+	"zxdg_exporter_v2", 1, 2, exporter_requests, 0, NULL,
+};
+
+static int load(void) {
+	void *lib = dlopen("libwayland-client.so.0", RTLD_NOW | RTLD_LOCAL);
+	if (lib == NULL) {
+		return 0;
+	}
+
+#define LOAD(field, symbol) if ((*(void **)(&wl.field) = dlsym(lib, symbol)) == NULL) { dlclose(lib); return 0; }
+	LOAD(display_create_queue, "wl_display_create_queue");
+	LOAD(display_roundtrip_queue, "wl_display_roundtrip_queue");
+	LOAD(display_flush, "wl_display_flush");
+	LOAD(event_queue_destroy, "wl_event_queue_destroy");
+	LOAD(proxy_create_wrapper, "wl_proxy_create_wrapper");
+	LOAD(proxy_wrapper_destroy, "wl_proxy_wrapper_destroy");
+	LOAD(proxy_set_queue, "wl_proxy_set_queue");
+	LOAD(proxy_marshal_constructor, "wl_proxy_marshal_constructor");
+	LOAD(proxy_marshal_constructor_versioned, "wl_proxy_marshal_constructor_versioned");
+	LOAD(proxy_marshal, "wl_proxy_marshal");
+	LOAD(proxy_add_listener, "wl_proxy_add_listener");
+	LOAD(proxy_destroy, "wl_proxy_destroy");
+	LOAD(registry_interface, "wl_registry_interface");
+	LOAD(surface_interface, "wl_surface_interface");
+#undef LOAD
+
+	exporter_export_types[0] = &exported_interface;
+	exporter_export_types[1] = wl.surface_interface;
+	return 1;
+}
+
+typedef struct {
+	struct wl_display *display;
+	struct wl_event_queue *queue;
+	void *wrapper;
+	struct wl_proxy *registry;
+	struct wl_proxy *exporter;
+	struct wl_proxy *exported;
+	char *handle;
+} export;
+
+static void registryGlobal(void *data, struct wl_proxy *registry, uint32_t name, const char *interface, uint32_t version) {
+	export *ex = data;
+	if (ex->exporter == NULL && strcmp(interface, exporter_interface.name) == 0) {
+		// wl_registry_bind
+		ex->exporter = wl.proxy_marshal_constructor_versioned(registry, 0, &exporter_interface, 1, name, exporter_interface.name, 1, NULL);
+	}
+}
+
+static void registryGlobalRemove(void *data, struct wl_proxy *registry, uint32_t name) {}
+
+static void (*registryListener[])(void) = {
+	(void (*)(void))registryGlobal,
+	(void (*)(void))registryGlobalRemove,
+};
+
+static void exportedHandle(void *data, struct wl_proxy *exported, const char *handle) {
+	export *ex = data;
+	free(ex->handle);
+	ex->handle = strdup(handle);
+}
+
+static void (*exportedListener[])(void) = {
+	(void (*)(void))exportedHandle,
+};
+
+static void unexportSurface(export *ex) {
+	if (ex->exported != NULL) {
+		wl.proxy_marshal(ex->exported, 0); // zxdg_exported_v2.destroy
+		wl.proxy_destroy(ex->exported);
+	}
+	if (ex->exporter != NULL) {
+		wl.proxy_marshal(ex->exporter, 0); // zxdg_exporter_v2.destroy
+		wl.proxy_destroy(ex->exporter);
+	}
+	if (ex->registry != NULL) {
+		wl.proxy_destroy(ex->registry);
+	}
+	if (ex->wrapper != NULL) {
+		wl.proxy_wrapper_destroy(ex->wrapper);
+	}
+	wl.display_flush(ex->display);
+	if (ex->queue != NULL) {
+		wl.event_queue_destroy(ex->queue);
+	}
+	free(ex->handle);
+	free(ex);
+}
+
+// MAX_ROUNDTRIPS is the number of roundtrips waiting for the handle of the exported surface.
+#define MAX_ROUNDTRIPS 10
+
+// exportSurface exports the surface on a dedicated event queue, so it doesn't
+// interfere with the one of the application.
+static export *exportSurface(void *display, void *surface) {
+	export *ex = calloc(1, sizeof(export));
+	if (ex == NULL) {
+		return NULL;
+	}
+	ex->display = display;
+
+	ex->queue = wl.display_create_queue(ex->display);
+	ex->wrapper = wl.proxy_create_wrapper(ex->display);
+	if (ex->queue == NULL || ex->wrapper == NULL) {
+		unexportSurface(ex);
+		return NULL;
+	}
+	wl.proxy_set_queue(ex->wrapper, ex->queue);
+
+	// wl_display_get_registry
+	ex->registry = wl.proxy_marshal_constructor(ex->wrapper, 1, wl.registry_interface, NULL);
+	if (ex->registry == NULL) {
+		unexportSurface(ex);
+		return NULL;
+	}
+	wl.proxy_add_listener(ex->registry, registryListener, ex);
+
+	if (wl.display_roundtrip_queue(ex->display, ex->queue) < 0 || ex->exporter == NULL) {
+		unexportSurface(ex);
+		return NULL;
+	}
+
+	// zxdg_exporter_v2.export_toplevel
+	ex->exported = wl.proxy_marshal_constructor(ex->exporter, 1, &exported_interface, NULL, surface);
+	if (ex->exported == NULL) {
+		unexportSurface(ex);
+		return NULL;
+	}
+	wl.proxy_add_listener(ex->exported, exportedListener, ex);
+
+	// The handle is usually received by the first roundtrip, the number of roundtrips is bounded
+	// so the dialog is shown without parent when the compositor never sends it.
+	for (int i = 0; ex->handle == NULL; i++) {
+		if (i == MAX_ROUNDTRIPS || wl.display_roundtrip_queue(ex->display, ex->queue) < 0) {
+			unexportSurface(ex);
+			return NULL;
+		}
+	}
+
+	return ex;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"sync"
+	"unsafe"
+)
+
+var (
+	waylandOnce   sync.Once
+	waylandLoaded bool
+)
+
+// exportWaylandSurface exports the given toplevel surface using xdg-foreign, so it can be
+// the parent of the dialogs. The returned function must be called to revoke the handle.
+func exportWaylandSurface(display, surface unsafe.Pointer) (string, func(), error) {
+	waylandOnce.Do(func() {
+		waylandLoaded = C.load() != 0
+	})
+	if !waylandLoaded {
+		return "", nil, errors.New("unable to load libwayland-client")
+	}
+
+	ex := C.exportSurface(display, surface)
+	if ex == nil {
+		return "", nil, errors.New("unable to export the surface using xdg-foreign")
+	}
+
+	return C.GoString(ex.handle), func() { C.unexportSurface(ex) }, nil
+}
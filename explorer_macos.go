@@ -6,110 +6,289 @@ package gexplorer
 /*
 #cgo CFLAGS: -Werror -xobjective-c -fmodules -fobjc-arc
 
+#include <stdbool.h>
+#include <stdlib.h>
 #import <Appkit/AppKit.h>
 
+typedef struct {
+	char * title;
+	char * prompt;
+	char * folder;
+	char * name;
+	char * ext;
+	char * mime;
+	bool modal;
+} dialogOptions;
+
 // Defined on explorer_macos.m file.
-extern void exportFile(CFTypeRef viewRef, int32_t id, char * name);
-extern void importFile(CFTypeRef viewRef, int32_t id, char * ext);
-extern void importFiles(CFTypeRef viewRef, int32_t id, char * ext);
+extern void exportFile(CFTypeRef viewRef, int32_t id, dialogOptions opts);
+extern void importFile(CFTypeRef viewRef, int32_t id, dialogOptions opts);
+extern void importFiles(CFTypeRef viewRef, int32_t id, dialogOptions opts);
+extern void importDirectories(CFTypeRef viewRef, int32_t id, dialogOptions opts, bool multiple);
+extern void cancelDialog(int32_t id);
+extern void revealFile(char * path);
+extern void openFile(char * path);
 */
 import "C"
 
 import (
+	"context"
+	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync/atomic"
 	"unsafe"
+	"weak"
 )
 
-type explorer struct {
+// defaultBackend is the backend used by NewExplorer.
+const defaultBackend = "appkit"
+
+func init() {
+	RegisterBackend("appkit", func(run RunHandler) Backend {
+		return newAppKit(run)
+	})
+}
+
+// appkit opens file explorers using the AppKit panels.
+type appkit struct {
+	id     int32
 	run    RunHandler
 	view   C.CFTypeRef
 	result chan result
 }
 
-func newExplorer(run RunHandler) *explorer {
-	return &explorer{
+func newAppKit(run RunHandler) *appkit {
+	a := &appkit{
+		id:     atomic.AddInt32(counter, 1),
 		run:    run,
 		result: make(chan result),
 	}
+
+	active.Store(a.id, weak.Make(a))
+	runtime.AddCleanup(a, func(id int32) { active.Delete(id) }, a.id)
+
+	return a
 }
 
-func (e *Explorer) setView(v uintptr) {
-	e.view = C.CFTypeRef(v)
+// SetView implements ViewSetter.
+func (a *appkit) SetView(v uintptr) {
+	a.view = C.CFTypeRef(v)
 }
 
-func (e *Explorer) importFile(extensions ...string) (string, error) {
-	for i, ext := range extensions {
-		extensions[i] = strings.TrimPrefix(ext, ".")
-	}
+// OpenFile implements Backend.
+func (a *appkit) OpenFile(ctx context.Context, opts *Options) (*Selection, error) {
+	copts := dialogOptions(opts)
+	return a.show(ctx, func() {
+		defer freeDialogOptions(copts)
+		C.importFile(a.view, C.int32_t(a.id), copts)
+	})
+}
+
+// OpenFiles implements Backend.
+func (a *appkit) OpenFiles(ctx context.Context, opts *Options) (*Selection, error) {
+	copts := dialogOptions(opts)
+	return a.show(ctx, func() {
+		defer freeDialogOptions(copts)
+		C.importFiles(a.view, C.int32_t(a.id), copts)
+	})
+}
 
-	cextensions := C.CString(strings.Join(extensions, ","))
-	e.run(func() {
-		C.importFile(e.view, C.int32_t(e.id), cextensions)
+// OpenDirectory implements Backend.
+func (a *appkit) OpenDirectory(ctx context.Context, opts *Options) (*Selection, error) {
+	copts := dialogOptions(opts)
+	return a.show(ctx, func() {
+		defer freeDialogOptions(copts)
+		C.importDirectories(a.view, C.int32_t(a.id), copts, C.bool(opts.Multiple))
 	})
+}
 
-	resp := <-e.result
-	if resp.error != nil {
-		return "", resp.error
+// Capabilities implements CapabilitiesReporter.
+// The panels don't allow the user to switch between filters, they are merged.
+func (a *appkit) Capabilities() (Capabilities, error) {
+	return Capabilities{
+		OpenFile:        true,
+		OpenFiles:       true,
+		SaveFile:        true,
+		SaveFiles:       true,
+		OpenDirectory:   true,
+		OpenDirectories: true,
+		RevealFile:      true,
+		OpenWith:        true,
+		Filters:         true,
+		Folder:          true,
+		AcceptLabel:     true,
+		Modal:           true,
+	}, nil
+}
+
+// RevealFile implements FileRevealer.
+func (a *appkit) RevealFile(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-	return resp.filenames[0], nil
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	cpath := C.CString(path)
+	a.run(func() {
+		defer C.free(unsafe.Pointer(cpath))
+		C.revealFile(cpath)
+	})
+	return nil
 }
 
-func (e *Explorer) importFiles(extensions ...string) ([]string, error) {
-	for i, ext := range extensions {
-		extensions[i] = strings.TrimPrefix(ext, ".")
+// OpenWith implements FileOpener.
+// The user can't be asked which application opens the file, so ErrNotAvailable is returned when ask is set.
+func (a *appkit) OpenWith(ctx context.Context, path string, ask bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ask {
+		return fmt.Errorf("%w: choosing the application isn't supported", ErrNotAvailable)
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
 	}
 
-	cextensions := C.CString(strings.Join(extensions, ","))
-	e.run(func() {
-		C.importFiles(e.view, C.int32_t(e.id), cextensions)
+	cpath := C.CString(path)
+	a.run(func() {
+		defer C.free(unsafe.Pointer(cpath))
+		C.openFile(cpath)
 	})
+	return nil
+}
+
+// show shows the panel opened by the given function and waits for the selection.
+func (a *appkit) show(ctx context.Context, panel func()) (*Selection, error) {
+	a.run(panel)
 
-	resp := <-e.result
+	resp := a.wait(ctx)
 	if resp.error != nil {
 		return nil, resp.error
 	}
-	return resp.filenames, nil
+	return &Selection{Paths: resp.filenames, URIs: resp.uris, Writable: true}, nil
 }
 
-func (e *Explorer) exportFile(name string) (string, error) {
-	cname := C.CString(name)
-	e.run(func() {
-		C.exportFile(e.view, C.int32_t(e.id), cname)
+// SaveFile implements Backend.
+func (a *appkit) SaveFile(ctx context.Context, opts *Options) (*Selection, error) {
+	copts := dialogOptions(opts)
+	return a.show(ctx, func() {
+		defer freeDialogOptions(copts)
+		C.exportFile(a.view, C.int32_t(a.id), copts)
 	})
+}
 
-	resp := <-e.result
-	if resp.error != nil {
-		return "", resp.error
+// dialogOptions converts the given options to their C form.
+// Its strings must be freed using freeDialogOptions.
+// The panels don't allow the user to switch between filters, so they are all merged.
+// The panels only support extensions and content types, so the patterns are reduced to their extension.
+func dialogOptions(opts *Options) C.dialogOptions {
+	var extensions, mimes []string
+	for _, filter := range opts.filters() {
+		for _, pattern := range filter.Patterns {
+			// Only the extension of the patterns can be used (such as `gz` for `*.tar.gz`).
+			ext := strings.TrimPrefix(filepath.Ext(pattern), ".")
+			if ext != "" && !strings.ContainsAny(ext, "*?[") {
+				extensions = appendUnique(extensions, ext)
+			}
+		}
+		mimes = append(mimes, filter.MIMETypes...)
+	}
+
+	name, folder := opts.Name, opts.Folder
+	if opts.File != "" {
+		name = filepath.Base(opts.File)
+		if folder == "" {
+			folder = filepath.Dir(opts.File)
+		}
+	}
+
+	return C.dialogOptions{
+		title:  C.CString(opts.Title),
+		prompt: C.CString(opts.AcceptLabel),
+		folder: C.CString(folder),
+		name:   C.CString(name),
+		ext:    C.CString(strings.Join(extensions, ",")),
+		mime:   C.CString(strings.Join(mimes, ",")),
+		modal:  C.bool(!opts.Modeless),
 	}
-	return resp.filenames[0], nil
+}
 
+// freeDialogOptions frees the strings of the given options, once the panel is configured.
+func freeDialogOptions(copts C.dialogOptions) {
+	C.free(unsafe.Pointer(copts.title))
+	C.free(unsafe.Pointer(copts.prompt))
+	C.free(unsafe.Pointer(copts.folder))
+	C.free(unsafe.Pointer(copts.name))
+	C.free(unsafe.Pointer(copts.ext))
+	C.free(unsafe.Pointer(copts.mime))
+}
+
+// wait waits for the result of the current dialog.
+// When the context is done before, the dialog is cancelled and the context's error is returned.
+func (a *appkit) wait(ctx context.Context) result {
+	select {
+	case resp := <-a.result:
+		return resp
+	case <-ctx.Done():
+		a.run(func() {
+			C.cancelDialog(C.int32_t(a.id))
+		})
+
+		<-a.result // The completion handler is always called.
+		return result{error: ctx.Err()}
+	}
 }
 
 //export importCallback
 func importCallback(id int32, u *C.char) {
-	if v, ok := active.Load(id); ok {
-		v.(*explorer).result <- newPath([]*C.char{u})
+	if a := lookup(id); a != nil {
+		a.result <- newPath([]*C.char{u})
 	}
 }
 
 //export importsCallback
 func importsCallback(id int32, count int32, u **C.char) {
-	if v, ok := active.Load(id); ok {
-		v.(*explorer).result <- newPath(unsafe.Slice(u, count))
+	if a := lookup(id); a != nil {
+		a.result <- newPath(unsafe.Slice(u, count))
 	}
 }
 
 //export exportCallback
 func exportCallback(id int32, u *C.char) {
-	if v, ok := active.Load(id); ok {
-		v.(*explorer).result <- newPath([]*C.char{u})
+	if a := lookup(id); a != nil {
+		a.result <- newPath([]*C.char{u})
+	}
+}
+
+// lookup returns the active backend of the given id, nil if it has been collected.
+func lookup(id int32) *appkit {
+	v, ok := active.Load(id)
+	if !ok {
+		return nil
 	}
+	return v.(weak.Pointer[appkit]).Value()
 }
 
 func newPath(urls []*C.char) result {
 	res := result{
 		filenames: make([]string, len(urls)),
+		uris:      make([]string, len(urls)),
 	}
 
 	for i, u := range urls {
@@ -129,6 +308,7 @@ func newPath(urls []*C.char) result {
 		}
 
 		res.filenames[i] = path
+		res.uris[i] = name
 	}
 
 	return res
@@ -16,23 +16,83 @@
 #import <Appkit/AppKit.h>
 #import <UniformTypeIdentifiers/UniformTypeIdentifiers.h>
 
-void exportFile(CFTypeRef viewRef, int32_t id, char * name) {
-	NSView *view = (__bridge NSView *)viewRef;
+// panels holds the running panels by explorer id, so they can be cancelled.
+static NSMutableDictionary<NSNumber*, NSSavePanel*> *panels;
+
+static void registerPanel(int32_t id, NSSavePanel *panel) {
+	if (panels == nil) {
+		panels = [[NSMutableDictionary alloc] init];
+	}
+	panels[@(id)] = panel;
+}
 
-	NSSavePanel *panel = [NSSavePanel savePanel];
+static void unregisterPanel(int32_t id) {
+	[panels removeObjectForKey:@(id)];
+}
 
-    [panel setNameFieldStringValue:@(name)];
-	[panel beginSheetModalForWindow:[view window] completionHandler:^(NSModalResponse result){ // FIXME: NSSavePanel: 0x100890620> running implicitly; please run panels using NSSavePanel rather than NSApplication.
-		if (result == NSModalResponseOK) {
-			exportCallback(id, (char *)[[[panel URL] absoluteString] UTF8String]);
-		} else {
-		    exportCallback(id, (char *)(""));
-		}
-	}];
+void cancelDialog(int32_t id) {
+	NSSavePanel *panel = panels[@(id)];
+	if (panel != nil) {
+		[panel cancel:nil];
+	}
+}
+
+// configurePanel applies the options shared by all the panels.
+static void configurePanel(NSSavePanel *panel, dialogOptions opts) {
+	NSString *title = @(opts.title);
+	if ([title length] > 0) {
+		[panel setTitle:title];
+		[panel setMessage:title]; // The title isn't displayed when the panel is a sheet.
+	}
+
+	NSString *prompt = @(opts.prompt);
+	if ([prompt length] > 0) {
+		[panel setPrompt:prompt];
+	}
+
+	NSString *folder = @(opts.folder);
+	if ([folder length] > 0) {
+		[panel setDirectoryURL:[NSURL fileURLWithPath:folder isDirectory:YES]];
+	}
+}
+
+// beginPanel displays the panel, as a sheet of the view's window when it is modal.
+static void beginPanel(NSSavePanel *panel, NSView *view, int32_t id, bool modal, void (^handler)(NSModalResponse result)) {
+	registerPanel(id, panel);
+
+	void (^completion)(NSModalResponse result) = ^(NSModalResponse result){
+		unregisterPanel(id);
+		handler(result);
+	};
+
+	if (modal && view != nil) {
+		[panel beginSheetModalForWindow:[view window] completionHandler:completion]; // FIXME: NSSavePanel: 0x100890620> running implicitly; please run panels using NSSavePanel rather than NSApplication.
+	} else {
+		[panel beginWithCompletionHandler:completion];
+	}
 }
 
-void importFile(CFTypeRef viewRef, int32_t id, char * ext) {
+// wildcardContentType returns the content type conforming to all the types of the given media type (such as `image`).
+static UTType *wildcardContentType(NSString *media) {
+    if ([media isEqualToString:@"image"]) {
+        return UTTypeImage;
+    } else if ([media isEqualToString:@"audio"]) {
+        return UTTypeAudio;
+    } else if ([media isEqualToString:@"video"]) {
+        return UTTypeMovie;
+    } else if ([media isEqualToString:@"text"]) {
+        return UTTypeText;
+    } else if ([media isEqualToString:@"font"]) {
+        return UTTypeFont;
+    } else if ([media isEqualToString:@"*"]) {
+        return UTTypeItem;
+    }
+    return nil;
+}
+
+static NSArray<UTType*> *allowedContentTypes(char * ext, char * mime) {
     NSMutableArray<NSString*> *exts = [[@(ext) componentsSeparatedByString:@","] mutableCopy];
+    NSMutableArray<NSString*> *mimes = [[@(mime) componentsSeparatedByString:@","] mutableCopy];
     NSMutableArray<UTType*> *contentTypes = [[NSMutableArray alloc]init];
 
     int i;
@@ -42,58 +102,104 @@ void importFile(CFTypeRef viewRef, int32_t id, char * ext) {
             [contentTypes addObject:utt];
         }
      }
+    for (i = 0; i < [mimes count]; i++) {
+        UTType * utt = nil;
+        if ([mimes[i] hasSuffix:@"/*"]) {
+            utt = wildcardContentType([mimes[i] substringToIndex:[mimes[i] length] - 2]);
+        } else {
+            utt = [UTType typeWithMIMEType:mimes[i]];
+        }
+        if (utt != nil){
+            [contentTypes addObject:utt];
+        }
+     }
 
+    return [NSArray arrayWithArray:contentTypes];
+}
+
+static void importsResult(NSOpenPanel *panel, int32_t id, NSModalResponse result) {
+	if (result == NSModalResponseOK) {
+		NSArray* urls = [panel URLs];
+		NSInteger count = [urls count];
+
+		char* results[count];
+		for(int i = 0; i < count; i++)	{
+			results[i] = (char *)[[[urls objectAtIndex:i] absoluteString] UTF8String];
+		}
+
+		importsCallback(id, count, results);
+	} else {
+	    importCallback(id, (char *)("")); // Use the single import to ease the implementation.
+	}
+}
+
+void exportFile(CFTypeRef viewRef, int32_t id, dialogOptions opts) {
+	NSView *view = (__bridge NSView *)viewRef;
+
+	NSSavePanel *panel = [NSSavePanel savePanel];
+	configurePanel(panel, opts);
+
+    [panel setNameFieldStringValue:@(opts.name)];
+	beginPanel(panel, view, id, opts.modal, ^(NSModalResponse result){
+		if (result == NSModalResponseOK) {
+			exportCallback(id, (char *)[[[panel URL] absoluteString] UTF8String]);
+		} else {
+		    exportCallback(id, (char *)(""));
+		}
+	});
+}
+
+void importFile(CFTypeRef viewRef, int32_t id, dialogOptions opts) {
 	NSOpenPanel *panel = [NSOpenPanel openPanel];
-    [panel setAllowedContentTypes:[NSArray arrayWithArray:contentTypes]];
+	configurePanel(panel, opts);
+    [panel setAllowedContentTypes:allowedContentTypes(opts.ext, opts.mime)];
 
 	NSView *view = (__bridge NSView *)viewRef;
-	[panel beginSheetModalForWindow:[view window] completionHandler:^(NSModalResponse result){ // FIXME: NSOpenPanel: 0x100989b00> running implicitly; please run panels using NSSavePanel rather than NSApplication.
+	beginPanel(panel, view, id, opts.modal, ^(NSModalResponse result){
 		if (result == NSModalResponseOK) {
 			importCallback(id, (char *)[[[panel URL] absoluteString] UTF8String]);
 		} else {
 			importCallback(id, (char *)(""));
 		}
-	}];
+	});
 }
 
-void importFiles(CFTypeRef viewRef, int32_t id, char * ext) {
+void importFiles(CFTypeRef viewRef, int32_t id, dialogOptions opts) {
 	NSView *view = (__bridge NSView *)viewRef;
 
 	NSOpenPanel *panel = [NSOpenPanel openPanel];
+	configurePanel(panel, opts);
 	// [panel setCanChooseFiles:YES];
 	// [panel setCanChooseDirectories:NO];
 	[panel setAllowsMultipleSelection:YES];
+    [panel setAllowedContentTypes:allowedContentTypes(opts.ext, opts.mime)];
 
-    NSMutableArray<NSString*> *exts = [[@(ext) componentsSeparatedByString:@","] mutableCopy];
-    NSMutableArray<UTType*> *contentTypes = [[NSMutableArray alloc]init];
-
-    int i;
-    for (i = 0; i < [exts count]; i++) {
-        UTType * utt = [UTType typeWithFilenameExtension:exts[i]];
-        if (utt != nil){
-            [contentTypes addObject:utt];
-        }
-     }
-    [panel setAllowedContentTypes:[NSArray arrayWithArray:contentTypes]];
+	beginPanel(panel, view, id, opts.modal, ^(NSModalResponse result){
+		importsResult(panel, id, result);
+	});
+}
 
-	[panel beginSheetModalForWindow:[view window] completionHandler:^(NSModalResponse result){ // FIXME: NSOpenPanel: 0x100989b00> running implicitly; please run panels using NSSavePanel rather than NSApplication.
-		if (result == NSModalResponseOK) {
-			NSArray* urls = [panel URLs];
-			NSInteger count = [urls count];
+void importDirectories(CFTypeRef viewRef, int32_t id, dialogOptions opts, bool multiple) {
+	NSView *view = (__bridge NSView *)viewRef;
 
-			char* results[count];
-			for(int i = 0; i < count; i++)	{
-				results[i] = (char *)[[[urls objectAtIndex:i] absoluteString] UTF8String];
-			}
+	NSOpenPanel *panel = [NSOpenPanel openPanel];
+	configurePanel(panel, opts);
+	[panel setCanChooseFiles:NO];
+	[panel setCanChooseDirectories:YES];
+	[panel setCanCreateDirectories:YES];
+	[panel setAllowsMultipleSelection:multiple];
+
+	beginPanel(panel, view, id, opts.modal, ^(NSModalResponse result){
+		importsResult(panel, id, result);
+	});
+}
 
-			// for(int i = 0; i < count; i++)
-			// {
-			// 	NSLog(@"URL: %s", results[i]);
-			// }
+void revealFile(char * path) {
+	NSURL *url = [NSURL fileURLWithPath:@(path)];
+	[[NSWorkspace sharedWorkspace] activateFileViewerSelectingURLs:@[url]];
+}
 
-			importsCallback(id, count, results);
-		} else {
-		    importCallback(id, (char *)("")); // Use the single import to ease the implementation.
-		}
-	}];
-}
\ No newline at end of file
+void openFile(char * path) {
+	NSURL *url = [NSURL fileURLWithPath:@(path)];
+	[[NSWorkspace sharedWorkspace] openURL:url];
+}
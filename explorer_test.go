@@ -0,0 +1,132 @@
+package gexplorer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mdouchement/gexplorer"
+	"github.com/mdouchement/gexplorer/gexplorertest"
+)
+
+// dialogDelay is the time the first dialog of the tests stays shown.
+const dialogDelay = 200 * time.Millisecond
+
+// waitFor polls the given condition until it's true or fails the test after a second.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// choose shows a file dialog on a new goroutine, its result being sent to the returned channel.
+func choose(ctx context.Context, e *gexplorer.Explorer) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := e.ChooseFileContext(ctx)
+		done <- err
+	}()
+	return done
+}
+
+func TestQueueBusy(t *testing.T) {
+	const callers = 4
+
+	e, backend := gexplorertest.NewExplorer(gexplorertest.Response{Paths: []string{"0"}, Delay: dialogDelay})
+	for i := 1; i < callers; i++ {
+		backend.Script(gexplorertest.Response{Paths: []string{fmt.Sprint(i)}})
+	}
+
+	paths := make([]chan string, callers)
+	for i := range paths {
+		paths[i] = make(chan string, 1)
+		go func() {
+			path, err := e.ChooseFileContext(context.Background())
+			if err != nil {
+				t.Errorf("caller %d: %v", i, err)
+			}
+			paths[i] <- path
+		}()
+
+		// The callers are queued one after the other, to know their request order.
+		if i == 0 {
+			waitFor(t, func() bool { return len(backend.Calls()) == 1 })
+		} else {
+			waitFor(t, func() bool { return gexplorer.Waiters(e) == i })
+		}
+	}
+
+	for i, ch := range paths {
+		if path := <-ch; path != fmt.Sprint(i) {
+			t.Errorf("caller %d: got response %q, expected %q", i, path, fmt.Sprint(i))
+		}
+	}
+	if n := backend.Pending(); n != 0 {
+		t.Errorf("%d responses not consumed", n)
+	}
+}
+
+func TestRejectBusy(t *testing.T) {
+	e, backend := gexplorertest.NewExplorer(
+		gexplorertest.Response{Paths: []string{"first"}, Delay: dialogDelay},
+		gexplorertest.Response{Paths: []string{"second"}},
+	)
+	e.SetBusyPolicy(gexplorer.RejectBusy)
+
+	first := choose(context.Background(), e)
+	waitFor(t, func() bool { return len(backend.Calls()) == 1 })
+
+	if _, err := e.ChooseFileContext(context.Background()); !errors.Is(err, gexplorer.ErrBusy) {
+		t.Errorf("got %v while a dialog is shown, expected %v", err, gexplorer.ErrBusy)
+	}
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+
+	// The dialog is done, so the next one is shown.
+	if _, err := e.ChooseFileContext(context.Background()); err != nil {
+		t.Errorf("got %v once the dialog is done", err)
+	}
+}
+
+func TestQueueBusyCancel(t *testing.T) {
+	e, backend := gexplorertest.NewExplorer(
+		gexplorertest.Response{Paths: []string{"first"}, Delay: dialogDelay},
+		gexplorertest.Response{Paths: []string{"second"}},
+	)
+
+	first := choose(context.Background(), e)
+	waitFor(t, func() bool { return len(backend.Calls()) == 1 })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := choose(ctx, e)
+	waitFor(t, func() bool { return gexplorer.Waiters(e) == 1 })
+
+	cancel()
+	if err := <-queued; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v for the cancelled dialog, expected %v", err, context.Canceled)
+	}
+	if n := gexplorer.Waiters(e); n != 0 {
+		t.Errorf("%d waiters left behind", n)
+	}
+
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+	if n := len(backend.Calls()); n != 1 {
+		t.Errorf("the cancelled dialog has been shown, %d calls", n)
+	}
+
+	// The turn isn't held by the cancelled dialog.
+	if _, err := e.ChooseFileContext(context.Background()); err != nil {
+		t.Errorf("got %v once the dialog is done", err)
+	}
+}
@@ -3,22 +3,6 @@
 
 package gexplorer
 
-type explorer struct{}
-
-func newExplorer(_ RunHandler) *explorer {
-	return new(explorer)
-}
-
-func (e *Explorer) setView(_ uintptr) {}
-
-func (e *Explorer) importFile(_ ...string) (string, error) {
-	return "", ErrNotAvailable
-}
-
-func (e *Explorer) importFiles(_ ...string) ([]string, error) {
-	return nil, ErrNotAvailable
-}
-
-func (e *Explorer) exportFile(_ string) (string, error) {
-	return "", ErrNotAvailable
-}
+// defaultBackend is the backend used by NewExplorer.
+// The current OS isn't supported so no backend is registered, other ones can still be used.
+const defaultBackend = ""
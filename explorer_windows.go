@@ -1,8 +1,13 @@
 package gexplorer
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 	"unicode/utf16"
 	"unsafe"
 
@@ -20,7 +25,10 @@ var (
 	_GetSaveFileName = _Dialog32.NewProc("GetSaveFileNameW")
 	_GetOpenFileName = _Dialog32.NewProc("GetOpenFileNameW")
 
+	_CommDlgExtendedError = _Dialog32.NewProc("CommDlgExtendedError")
+
 	// https://docs.microsoft.com/en-us/windows/win32/api/commdlg/ns-commdlg-openfilenamew
+	_FlagReadOnly         = uint32(0x00000001)
 	_FlagAllowMultiSelect = uint32(0x00000200)
 	_FlagFileMustExist    = uint32(0x00001000)
 	_FlagExplorer         = uint32(0x00080000)
@@ -30,6 +38,63 @@ var (
 
 	_FilePathLength       = uint32(65535)
 	_OpenFileStructLength = uint32(unsafe.Sizeof(_OpenFileName{}))
+
+	// https://docs.microsoft.com/en-us/windows/win32/api/shlobj_core/
+	_Shell32 = windows.NewLazySystemDLL("shell32.dll")
+
+	_SHBrowseForFolder   = _Shell32.NewProc("SHBrowseForFolderW")
+	_SHGetPathFromIDList = _Shell32.NewProc("SHGetPathFromIDListW")
+
+	_ILCreateFromPath           = _Shell32.NewProc("ILCreateFromPathW")
+	_ILFree                     = _Shell32.NewProc("ILFree")
+	_SHOpenFolderAndSelectItems = _Shell32.NewProc("SHOpenFolderAndSelectItems")
+	_ShellExecute               = _Shell32.NewProc("ShellExecuteW")
+	_SHOpenWithDialog           = _Shell32.NewProc("SHOpenWithDialog")
+
+	// https://learn.microsoft.com/en-us/windows/win32/api/shlobj_core/ne-shlobj_core-open_as_info_flags
+	_FlagOpenAsAllowRegistration = uint32(0x00000001)
+	_FlagOpenAsExec              = uint32(0x00000004)
+
+	// https://learn.microsoft.com/en-us/windows/win32/api/winuser/nf-winuser-showwindow
+	_ShowNormal = uintptr(1)
+
+	// HRESULT_FROM_WIN32(ERROR_CANCELLED), returned when the user cancels a Shell dialog.
+	_ErrorCancelled = uint32(0x800704C7)
+
+	// https://docs.microsoft.com/en-us/windows/win32/api/shlobj_core/ns-shlobj_core-browseinfow
+	_FlagReturnOnlyFSDirs = uint32(0x00000001)
+	_FlagEditBox          = uint32(0x00000010)
+	_FlagNewDialogStyle   = uint32(0x00000040)
+
+	// https://docs.microsoft.com/en-us/windows/win32/api/winuser/
+	_User32 = windows.NewLazySystemDLL("user32.dll")
+
+	_EnumThreadWindows = _User32.NewProc("EnumThreadWindows")
+	_PostMessage       = _User32.NewProc("PostMessageW")
+
+	_SendMessage = _User32.NewProc("SendMessageW")
+
+	// https://docs.microsoft.com/en-us/windows/win32/winmsg/wm-close
+	_MessageClose = uintptr(0x0010)
+
+	// https://docs.microsoft.com/en-us/windows/win32/api/shlobj_core/nc-shlobj_core-bffcallback
+	_MessageBrowseInitialized  = uintptr(1)
+	_MessageBrowseSetSelection = uintptr(0x0400 + 103) // WM_USER + 103
+
+	// closeWindow is the EnumThreadWindows callback which asks each window to close.
+	// Callbacks are a limited resource so it's created once.
+	closeWindow = windows.NewCallback(func(hwnd, _ uintptr) uintptr {
+		_PostMessage.Call(hwnd, _MessageClose, 0, 0)
+		return 1 // Continue the enumeration.
+	})
+
+	// selectFolder is the SHBrowseForFolder callback which selects the folder given as lParam.
+	selectFolder = windows.NewCallback(func(hwnd, msg, _, data uintptr) uintptr {
+		if msg == _MessageBrowseInitialized {
+			_SendMessage.Call(hwnd, _MessageBrowseSetSelection, 1, data)
+		}
+		return 0
+	})
 )
 
 type (
@@ -59,52 +124,213 @@ type (
 		DwReserved      uint32
 		FlagsEx         uint32
 	}
+
+	// _OpenAsInfo is defined at https://learn.microsoft.com/en-us/windows/win32/api/shlobj_core/ns-shlobj_core-openasinfo
+	_OpenAsInfo struct {
+		File  *uint16
+		Class *uint16
+		Flags uint32
+	}
+
+	// _BrowseInfo is defined at https://docs.microsoft.com/en-us/windows/win32/api/shlobj_core/ns-shlobj_core-browseinfow
+	_BrowseInfo struct {
+		Owner       uintptr
+		Root        uintptr
+		DisplayName *uint16
+		Title       *uint16
+		Flags       uint32
+		FnCallback  uintptr
+		LParam      uintptr
+		Image       int32
+	}
 )
 
-type explorer struct{}
+// defaultBackend is the backend used by NewExplorer.
+const defaultBackend = "win32"
 
-func newExplorer(_ RunHandler) *explorer {
-	return &explorer{}
+func init() {
+	RegisterBackend("win32", func(_ RunHandler) Backend {
+		return &win32{}
+	})
 }
 
-func (e *Explorer) setView(_ uintptr) {}
+// win32 opens file explorers using the common dialog box library.
+type win32 struct{}
 
-func (e *Explorer) importFile(extensions ...string) (string, error) {
-	pathUTF16 := make([]uint16, _FilePathLength)
+// OpenFile implements Backend.
+func (w *win32) OpenFile(ctx context.Context, opts *Options) (*Selection, error) {
+	return w.open(ctx, withMode(opts, false, false))
+}
 
-	open := _OpenFileName{
-		File:       &pathUTF16[0],
-		MaxFile:    _FilePathLength,
-		Filter:     buildFilter(extensions),
-		Flags:      _FlagExplorer | _FlagFileMustExist | _FlagForceShowHidden | _FlagDisableLinks,
-		StructSize: _OpenFileStructLength,
+// OpenFiles implements Backend.
+func (w *win32) OpenFiles(ctx context.Context, opts *Options) (*Selection, error) {
+	return w.open(ctx, withMode(opts, true, false))
+}
+
+// OpenDirectory implements Backend.
+func (w *win32) OpenDirectory(ctx context.Context, opts *Options) (*Selection, error) {
+	if opts.Multiple {
+		// SHBrowseForFolder doesn't support multiple selection.
+		return nil, ErrNotAvailable
 	}
+	return w.openDirectory(ctx, opts)
+}
 
-	if r, _, _ := _GetOpenFileName.Call(uintptr(unsafe.Pointer(&open))); r == 0 {
-		return "", ErrUserDecline
+// Capabilities implements CapabilitiesReporter.
+func (w *win32) Capabilities() (Capabilities, error) {
+	return Capabilities{
+		OpenFile:        true,
+		OpenFiles:       true,
+		SaveFile:        true,
+		SaveFiles:       true,
+		OpenDirectory:   true,
+		OpenDirectories: false, // SHBrowseForFolder doesn't support multiple selection.
+		RevealFile:      true,
+		OpenWith:        true,
+		OpenWithChooser: true,
+		Filters:         true,
+		FilterSelection: true,
+		Folder:          true,
+	}, nil
+}
+
+// RevealFile implements FileRevealer.
+func (w *win32) RevealFile(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	paths := decode(pathUTF16)
-	if len(paths) == 0 {
-		return "", ErrUserDecline
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	pathUTF16, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
 	}
 
-	return paths[0], nil
+	hr := withShell(func() uintptr {
+		pidl, _, _ := _ILCreateFromPath.Call(uintptr(unsafe.Pointer(pathUTF16)))
+		if pidl == 0 {
+			return uintptr(windows.E_FAIL)
+		}
+		defer _ILFree.Call(pidl)
+
+		// Given the absolute item, its folder is opened with the item selected.
+		hr, _, _ := _SHOpenFolderAndSelectItems.Call(pidl, 0, 0, 0)
+		return hr
+	})
+	return shellError(hr)
 }
 
-func (e *Explorer) importFiles(extensions ...string) ([]string, error) {
+// OpenWith implements FileOpener.
+// The user chooses the application using the "Open with" dialog when ask is set.
+func (w *win32) OpenWith(ctx context.Context, path string, ask bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	pathUTF16, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+
+	if !ask {
+		r := withShell(func() uintptr {
+			r, _, _ := _ShellExecute.Call(0, uintptr(unsafe.Pointer(utf16Ptr("open"))), uintptr(unsafe.Pointer(pathUTF16)), 0, 0, _ShowNormal)
+			return r
+		})
+		if r <= 32 {
+			return fmt.Errorf("%w: error code %d", ErrDialogFailed, r)
+		}
+		return nil
+	}
+
+	info := _OpenAsInfo{
+		File:  pathUTF16,
+		Flags: _FlagOpenAsAllowRegistration | _FlagOpenAsExec,
+	}
+
+	hr, err := runDialog(ctx, func() uintptr {
+		return withShell(func() uintptr {
+			hr, _, _ := _SHOpenWithDialog.Call(0, uintptr(unsafe.Pointer(&info)))
+			return hr
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return shellError(hr)
+}
+
+// withShell calls the given Shell function on a thread where COM is initialized, as they require it.
+func withShell(call func() uintptr) uintptr {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := windows.CoInitializeEx(0, windows.COINIT_APARTMENTTHREADED); err == nil {
+		defer windows.CoUninitialize()
+	}
+
+	return call()
+}
+
+// shellError returns the error of the given HRESULT, ErrUserDecline when the user cancelled the dialog.
+func shellError(hr uintptr) error {
+	switch uint32(hr) {
+	case 0: // S_OK
+		return nil
+	case _ErrorCancelled:
+		return ErrUserDecline
+	default:
+		return fmt.Errorf("%w: error code 0x%08x", ErrDialogFailed, uint32(hr))
+	}
+}
+
+func (w *win32) open(ctx context.Context, opts *Options) (*Selection, error) {
 	pathUTF16 := make([]uint16, _FilePathLength)
+	filters := opts.filters()
 
 	open := _OpenFileName{
-		File:       &pathUTF16[0],
-		MaxFile:    _FilePathLength,
-		Filter:     buildFilter(extensions),
-		Flags:      _FlagExplorer | _FlagFileMustExist | _FlagForceShowHidden | _FlagDisableLinks | _FlagAllowMultiSelect,
-		StructSize: _OpenFileStructLength,
+		File:        &pathUTF16[0],
+		MaxFile:     _FilePathLength,
+		Filter:      buildFilter(filters),
+		FilterIndex: uint32(opts.currentFilter(filters) + 1),
+		InitialDir:  utf16Ptr(opts.Folder),
+		Title:       utf16Ptr(opts.Title),
+		Flags:       _FlagExplorer | _FlagFileMustExist | _FlagForceShowHidden | _FlagDisableLinks,
+		StructSize:  _OpenFileStructLength,
+	}
+	if opts.Multiple {
+		open.Flags |= _FlagAllowMultiSelect
 	}
 
-	if r, _, _ := _GetOpenFileName.Call(uintptr(unsafe.Pointer(&open))); r == 0 {
-		return nil, ErrUserDecline
+	var code uintptr
+	r, err := runDialog(ctx, func() uintptr {
+		r, _, _ := _GetOpenFileName.Call(uintptr(unsafe.Pointer(&open)))
+		if r == 0 {
+			// The error code is bound to the thread of the dialog.
+			code, _, _ = _CommDlgExtendedError.Call()
+		}
+		return r
+	})
+	if err != nil {
+		return nil, err
+	}
+	if r == 0 {
+		return nil, dialogError(code)
 	}
 
 	paths := decode(pathUTF16)
@@ -112,56 +338,214 @@ func (e *Explorer) importFiles(extensions ...string) ([]string, error) {
 		return nil, ErrUserDecline
 	}
 
-	return paths, nil
+	selection := NewSelection(paths)
+	selection.Filter = selectedFilter(filters, open.FilterIndex)
+	selection.Writable = open.Flags&_FlagReadOnly == 0
+	return selection, nil
 }
 
-func (e *Explorer) exportFile(name string) (string, error) {
+// SaveFile implements Backend.
+func (w *win32) SaveFile(ctx context.Context, opts *Options) (*Selection, error) {
+	name, folder := opts.Name, opts.Folder
+	if opts.File != "" {
+		name = filepath.Base(opts.File)
+		if folder == "" {
+			folder = filepath.Dir(opts.File)
+		}
+	}
+
+	filters := opts.filters()
+	if len(filters) == 0 && filepath.Ext(name) != "" {
+		filters = []Filter{extensionFilter([]string{filepath.Ext(name)})}
+	}
+
 	pathUTF16 := make([]uint16, _FilePathLength)
 	copy(pathUTF16, windows.StringToUTF16(name))
 
 	open := _OpenFileName{
 		File:          &pathUTF16[0],
 		MaxFile:       _FilePathLength,
-		Filter:        buildFilter([]string{filepath.Ext(name)}),
+		Filter:        buildFilter(filters),
+		FilterIndex:   uint32(opts.currentFilter(filters) + 1),
 		FileExtension: uint16(strings.Index(name, filepath.Ext(name))),
+		InitialDir:    utf16Ptr(folder),
+		Title:         utf16Ptr(opts.Title),
 		Flags:         _FlagExplorer | _FlagOverwritePrompt,
 		StructSize:    _OpenFileStructLength,
 	}
 
-	if r, _, _ := _GetSaveFileName.Call(uintptr(unsafe.Pointer(&open))); r == 0 {
-		return "", ErrUserDecline
+	var code uintptr
+	r, err := runDialog(ctx, func() uintptr {
+		r, _, _ := _GetSaveFileName.Call(uintptr(unsafe.Pointer(&open)))
+		if r == 0 {
+			// The error code is bound to the thread of the dialog.
+			code, _, _ = _CommDlgExtendedError.Call()
+		}
+		return r
+	})
+	if err != nil {
+		return nil, err
+	}
+	if r == 0 {
+		return nil, dialogError(code)
 	}
 
 	paths := decode(pathUTF16)
 	if len(paths) == 0 {
-		return "", ErrUserDecline
+		return nil, ErrUserDecline
 	}
 
-	return paths[0], nil
+	selection := NewSelection(paths[:1])
+	selection.Filter = selectedFilter(filters, open.FilterIndex)
+	selection.Writable = true
+	return selection, nil
 }
 
-func buildFilter(extensions []string) *uint16 {
-	if len(extensions) <= 0 {
-		return nil
+func (w *win32) openDirectory(ctx context.Context, opts *Options) (*Selection, error) {
+	displayName := make([]uint16, windows.MAX_PATH)
+
+	browse := _BrowseInfo{
+		DisplayName: &displayName[0],
+		Title:       utf16Ptr(opts.Title),
+		Flags:       _FlagReturnOnlyFSDirs | _FlagEditBox | _FlagNewDialogStyle,
+	}
+
+	// The initial folder is selected by the callback once the dialog is initialized.
+	folder := utf16Ptr(opts.Folder)
+	if folder != nil {
+		browse.FnCallback = selectFolder
+		browse.LParam = uintptr(unsafe.Pointer(folder))
 	}
+	defer runtime.KeepAlive(folder)
 
-	for k, v := range extensions {
-		// Extension must have `*` wildcard, so `.jpg` must be `*.jpg`.
-		if !strings.HasPrefix(v, "*") {
-			extensions[k] = "*" + v
+	pidl, err := runDialog(ctx, func() uintptr {
+		// The new dialog style requires COM to be initialized on the calling thread.
+		if err := windows.CoInitializeEx(0, windows.COINIT_APARTMENTTHREADED); err == nil {
+			defer windows.CoUninitialize()
 		}
+
+		pidl, _, _ := _SHBrowseForFolder.Call(uintptr(unsafe.Pointer(&browse)))
+		return pidl
+	})
+	if err != nil {
+		if pidl != 0 {
+			windows.CoTaskMemFree(*(*unsafe.Pointer)(unsafe.Pointer(&pidl)))
+		}
+		return nil, err
+	}
+	if pidl == 0 {
+		return nil, ErrUserDecline
 	}
-	e := strings.ToUpper(strings.Join(extensions, ";"))
+	defer windows.CoTaskMemFree(*(*unsafe.Pointer)(unsafe.Pointer(&pidl)))
 
-	// That is a "string-pair", Windows have a Title and the Filter, for instance it could be:
-	// Images\0*.JPG;*.PNG\0\0
+	pathUTF16 := make([]uint16, windows.MAX_PATH)
+	if r, _, _ := _SHGetPathFromIDList.Call(pidl, uintptr(unsafe.Pointer(&pathUTF16[0]))); r == 0 {
+		return nil, ErrUserDecline
+	}
+
+	selection := NewSelection([]string{windows.UTF16ToString(pathUTF16)})
+	selection.Writable = true
+	return selection, nil
+}
+
+// dialogError returns the error of the given common dialog error code, ErrUserDecline when the user cancelled it.
+func dialogError(code uintptr) error {
+	if code == 0 {
+		return ErrUserDecline
+	}
+	return fmt.Errorf("%w: error code 0x%04x", ErrDialogFailed, code)
+}
+
+// runDialog runs the given dialog on a dedicated OS thread.
+// When the context is done before the dialog returns, the windows of that thread
+// are closed, which dismisses the dialog, and the context's error is returned.
+func runDialog(ctx context.Context, dialog func() uintptr) (uintptr, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	thread := make(chan uint32, 1)
+	done := make(chan uintptr, 1)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		thread <- windows.GetCurrentThreadId()
+		done <- dialog()
+	}()
+	tid := <-thread
+
+	select {
+	case r := <-done:
+		return r, nil
+	case <-ctx.Done():
+	}
+
+	// The dialog may not be displayed yet, so retry until it's closed.
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		_EnumThreadWindows.Call(uintptr(tid), closeWindow, 0)
+
+		select {
+		case r := <-done:
+			return r, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// utf16Ptr returns the NULL-terminated UTF-16 form of s, or nil when s is empty.
+func utf16Ptr(s string) *uint16 {
+	if s == "" {
+		return nil
+	}
+	return &windows.StringToUTF16(s)[0]
+}
+
+func buildFilter(filters []Filter) *uint16 {
+	if len(filters) <= 0 {
+		return nil
+	}
+
+	// Each filter is a "string-pair", Windows have a Title and the Filter, for instance it could be:
+	// Images\0*.JPG;*.PNG\0Documents\0*.PDF\0\0
 	// Where `\0` means NULL
-	f := windows.StringToUTF16(e + " " + e) // Use the filter as title so it appear `*.JPG;*.PNG` for the user.
-	f[len(e)] = 0                           // Replace the " " (space) with NULL.
-	f = append(f, uint16(0))                // Adding another NULL, because we need two.
+	var f []uint16
+	for _, filter := range filters {
+		e := strings.Join(filterPatterns(filter), ";")
+
+		name := filter.Name
+		if name == "" {
+			name = e // Use the filter as title so it appear `*.JPG;*.PNG` for the user.
+		}
+
+		f = append(f, windows.StringToUTF16(name)...) // Terminated by a NULL.
+		f = append(f, windows.StringToUTF16(e)...)
+	}
+	f = append(f, uint16(0)) // Adding another NULL, because we need two.
 	return &f[0]
 }
 
+// filterPatterns returns the patterns of the given filter, including the ones of its MIME types.
+// Windows matches them case-insensitively.
+func filterPatterns(filter Filter) []string {
+	patterns := filter.globs()
+	for i, pattern := range patterns {
+		patterns[i] = strings.ToUpper(pattern)
+	}
+	return patterns
+}
+
+// selectedFilter returns the filter at the given 1-based index, nil if out of range.
+func selectedFilter(filters []Filter, index uint32) *Filter {
+	if index > 0 && int(index) <= len(filters) {
+		return &filters[index-1]
+	}
+	return nil
+}
+
 func decode(p []uint16) []string {
 	paths := make([]string, 0)
 
@@ -0,0 +1,9 @@
+package gexplorer
+
+// Waiters returns the number of dialogs of the given Explorer waiting for their turn.
+func Waiters(e *Explorer) int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return len(e.waiters)
+}
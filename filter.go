@@ -0,0 +1,198 @@
+package gexplorer
+
+import (
+	"mime"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Filter is a named group of file types the user can pick in the dialog (such as "Images").
+type Filter struct {
+	// Name is the label of the filter displayed to the user.
+	Name string
+	// Patterns are the glob patterns matched against the filenames (such as `*.png`).
+	// They are matched case-insensitively, an extension (such as `.png`) is the same as `*.png`.
+	Patterns []string
+	// MIMETypes are the MIME types of the selectable files (such as `image/png`).
+	// A wildcard subtype matches all the types of a media type (such as `image/*`).
+	MIMETypes []string
+}
+
+// commonExtensions are the extensions of the common types of each media type, used to resolve
+// the MIME type wildcards (such as `image/*`) for the OSes only supporting extensions
+// when the shared-mime-info database isn't available.
+var commonExtensions = map[string][]string{
+	"image": {".png", ".jpg", ".jpeg", ".gif", ".bmp", ".webp", ".tif", ".tiff", ".svg", ".ico", ".heic", ".heif", ".avif"},
+	"audio": {".mp3", ".wav", ".ogg", ".oga", ".opus", ".flac", ".aac", ".m4a", ".wma", ".mid", ".midi"},
+	"video": {".mp4", ".m4v", ".mkv", ".webm", ".avi", ".mov", ".wmv", ".mpeg", ".mpg", ".ogv"},
+	"text":  {".txt", ".csv", ".tsv", ".md", ".html", ".htm", ".css", ".xml", ".ics", ".vcf"},
+	"font":  {".ttf", ".otf", ".woff", ".woff2"},
+}
+
+// filters returns the normalized filters of the options. When extensions are defined,
+// a filter matching all of them is put first.
+func (o *Options) filters() []Filter {
+	filters := make([]Filter, 0, len(o.Filters)+1)
+	if len(o.Extensions) > 0 {
+		filters = append(filters, extensionFilter(o.Extensions))
+	}
+
+	for _, filter := range o.Filters {
+		filters = append(filters, filter.normalize())
+	}
+	return filters
+}
+
+// currentFilter returns the index of Options.CurrentFilter in the given filters or -1 if not found.
+func (o *Options) currentFilter(filters []Filter) int {
+	if o.CurrentFilter == nil {
+		return -1
+	}
+
+	for i, filter := range filters {
+		if filter.Name == o.CurrentFilter.Name {
+			return i
+		}
+	}
+	return -1
+}
+
+// extensionFilter constructs the filter matching the given entries, which are either extensions
+// (such as `.jpg` or `JPG`), glob patterns (such as `*.tar.gz`) or MIME types (such as `text/csv` or `image/*`).
+// Known extensions are also resolved to their corresponding mime types.
+//
+// The filter is labeled with the descriptions of its types when known (such as "PNG image (*.png)").
+func extensionFilter(entries []string) Filter {
+	var filter Filter
+	var names, descriptions []string
+	for _, entry := range entries {
+		pattern, mt := parseFilterEntry(entry)
+		switch {
+		case pattern != "":
+			names = appendUnique(names, pattern)
+			filter.Patterns = appendUnique(filter.Patterns, pattern)
+			if mt != "" {
+				filter.MIMETypes = appendUnique(filter.MIMETypes, mt)
+			}
+		case mt != "":
+			names = appendUnique(names, mt)
+			filter.MIMETypes = appendUnique(filter.MIMETypes, mt)
+		default:
+			continue
+		}
+
+		if description := mimeTypeComment(mt); description != "" {
+			descriptions = appendUnique(descriptions, description)
+		}
+	}
+
+	filter.Name = strings.Join(names, ", ")
+	if len(descriptions) > 0 {
+		filter.Name = strings.Join(descriptions, ", ") + " (" + filter.Name + ")"
+	}
+	return filter
+}
+
+// parseFilterEntry returns the glob pattern and the MIME type of the given filter entry.
+// Extensions are converted to a pattern and their MIME type when known, glob patterns
+// and MIME types are returned as is. Both are lower cased.
+func parseFilterEntry(entry string) (pattern, mimeType string) {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+	switch {
+	case entry == "":
+		return "", ""
+	case strings.Contains(entry, "/"):
+		mt, _, err := mime.ParseMediaType(entry)
+		if err != nil {
+			return "", ""
+		}
+		return "", canonicalMIMEType(mt)
+	case strings.ContainsAny(entry, "*?["):
+		return entry, ""
+	}
+
+	ext := entry
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	return "*" + ext, typeByFilename("file" + ext)
+}
+
+// normalize returns the filter with its patterns and MIME types normalized the same way as Options.Extensions.
+func (f Filter) normalize() Filter {
+	filter := Filter{Name: f.Name}
+	for _, pattern := range f.Patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if strings.HasPrefix(pattern, ".") {
+			pattern = "*" + pattern
+		}
+		if pattern != "" {
+			filter.Patterns = appendUnique(filter.Patterns, pattern)
+		}
+	}
+	for _, mt := range f.MIMETypes {
+		if _, mt := parseFilterEntry(mt); mt != "" {
+			filter.MIMETypes = appendUnique(filter.MIMETypes, mt)
+		}
+	}
+
+	if filter.Name == "" {
+		filter.Name = strings.Join(append(slices.Clone(filter.Patterns), filter.MIMETypes...), ", ")
+	}
+	return filter
+}
+
+// globs returns the patterns of the filter, including the extensions of its MIME types,
+// for the OSes not supporting MIME types.
+func (f Filter) globs() []string {
+	globs := slices.Clone(f.Patterns)
+	for _, mt := range f.MIMETypes {
+		for _, ext := range extensionsByType(mt) {
+			globs = appendUnique(globs, "*"+strings.ToLower(ext))
+		}
+	}
+	return globs
+}
+
+// match reports whether the given filename matches one of the patterns or MIME types of the filter.
+// Patterns are matched case-insensitively, and the subclasses of the MIME types match (such as `text/csv` for `text/plain`).
+func (f Filter) match(name string) bool {
+	base := strings.ToLower(filepath.Base(name))
+	for _, pattern := range f.Patterns {
+		if ok, _ := filepath.Match(strings.ToLower(pattern), base); ok {
+			return true
+		}
+	}
+
+	if len(f.MIMETypes) > 0 {
+		mt := typeByFilename(name)
+		if mt == "" {
+			return false
+		}
+		for _, pattern := range f.MIMETypes {
+			if matchMIMEType(pattern, mt) || isA(mt, pattern) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchMIMEType reports whether the given MIME type matches the given pattern, which may have a wildcard subtype.
+func matchMIMEType(pattern, mt string) bool {
+	if major, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return major == "*" || strings.HasPrefix(mt, major+"/")
+	}
+	return pattern == mt
+}
+
+// appendUnique appends the given value when not already in the slice.
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
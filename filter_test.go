@@ -0,0 +1,118 @@
+package gexplorer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseFilterEntry(t *testing.T) {
+	useMIMEDatabase(t, nil, systemMIMEFixture)
+
+	tests := []struct {
+		entry    string
+		pattern  string
+		mimeType string
+	}{
+		{entry: ""},
+		{entry: "  "},
+		{entry: ".JPG", pattern: "*.jpg", mimeType: "image/jpeg"},
+		{entry: "JPG", pattern: "*.jpg", mimeType: "image/jpeg"},
+		{entry: ".csv", pattern: "*.csv", mimeType: "text/csv"},
+		{entry: "*.tar.gz", pattern: "*.tar.gz"},
+		{entry: "README*", pattern: "readme*"},
+		{entry: "image/*", mimeType: "image/*"},
+		{entry: "Text/CSV", mimeType: "text/csv"},
+		{entry: "text/csv; charset=utf-8", mimeType: "text/csv"},
+		{entry: "text/comma-separated-values", mimeType: "text/csv"},
+		{entry: "text/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			pattern, mimeType := parseFilterEntry(tt.entry)
+			if pattern != tt.pattern || mimeType != tt.mimeType {
+				t.Errorf("got (%q, %q), expected (%q, %q)", pattern, mimeType, tt.pattern, tt.mimeType)
+			}
+		})
+	}
+}
+
+func TestFilterNormalize(t *testing.T) {
+	useMIMEDatabase(t, nil, systemMIMEFixture)
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected Filter
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:     "named",
+			filter:   Filter{Name: "Images", Patterns: []string{".PNG"}},
+			expected: Filter{Name: "Images", Patterns: []string{"*.png"}},
+		},
+		{
+			name: "patterns",
+			filter: Filter{
+				Patterns: []string{" .JPG", "*.PNG", "", "*.png", "*.tar.gz"},
+			},
+			expected: Filter{
+				Name:     "*.jpg, *.png, *.tar.gz",
+				Patterns: []string{"*.jpg", "*.png", "*.tar.gz"},
+			},
+		},
+		{
+			name: "MIME types",
+			filter: Filter{
+				MIMETypes: []string{"IMAGE/*", "text/csv; charset=utf-8", "text/", "text/comma-separated-values"},
+			},
+			expected: Filter{
+				Name:      "image/*, text/csv",
+				MIMETypes: []string{"image/*", "text/csv"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter.normalize()
+			if filter.Name != tt.expected.Name {
+				t.Errorf("got name %q, expected %q", filter.Name, tt.expected.Name)
+			}
+			if !slices.Equal(filter.Patterns, tt.expected.Patterns) {
+				t.Errorf("got patterns %q, expected %q", filter.Patterns, tt.expected.Patterns)
+			}
+			if !slices.Equal(filter.MIMETypes, tt.expected.MIMETypes) {
+				t.Errorf("got MIME types %q, expected %q", filter.MIMETypes, tt.expected.MIMETypes)
+			}
+		})
+	}
+}
+
+func TestFilterGlobs(t *testing.T) {
+	t.Run("database", func(t *testing.T) {
+		useMIMEDatabase(t, nil, systemMIMEFixture)
+
+		filter := Filter{Patterns: []string{"*.tar.gz"}, MIMETypes: []string{"image/*", "text/csv"}}
+		expected := []string{"*.tar.gz", "*.png", "*.csv"}
+		if globs := filter.globs(); !slices.Equal(globs, expected) {
+			t.Errorf("got %q, expected %q", globs, expected)
+		}
+	})
+
+	t.Run("no database", func(t *testing.T) {
+		useMIMEDatabase(t)
+
+		filter := Filter{MIMETypes: []string{"image/*"}}
+		var expected []string
+		for _, ext := range commonExtensions["image"] {
+			expected = append(expected, "*"+ext)
+		}
+		slices.Sort(expected)
+		if globs := filter.globs(); !slices.Equal(globs, expected) {
+			t.Errorf("got %q, expected %q", globs, expected)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package gexplorer
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// foldersMutex serializes the updates of the last used folders made by this process.
+var foldersMutex sync.Mutex
+
+// lastFolder returns the options of a dialog opened in the last folder used for the purpose
+// of the given options, when its folder isn't defined. The given options are returned otherwise.
+func lastFolder(opts *Options) *Options {
+	if opts.Purpose == "" || opts.Folder != "" || opts.File != "" {
+		return opts
+	}
+
+	folder := loadFolders(foldersPath())[opts.Purpose]
+	if info, err := os.Stat(folder); folder == "" || err != nil || !info.IsDir() {
+		return opts // Removed meanwhile.
+	}
+
+	o := *opts
+	o.Folder = folder
+	return &o
+}
+
+// rememberFolder stores the folder of the given selection as the last folder used for the purpose of the given options.
+func rememberFolder(opts *Options, selection *Selection) {
+	if opts.Purpose == "" || len(selection.Paths) == 0 {
+		return
+	}
+
+	storeFolder(foldersPath(), opts.Purpose, filepath.Dir(selection.Paths[0])) // Best effort.
+}
+
+// foldersPath returns the path of the file storing the last used folders of the program,
+// under `$XDG_STATE_HOME` (or the user configuration directory on Windows and macOS).
+func foldersPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		switch runtime.GOOS {
+		case "windows", "darwin":
+			dir, _ = os.UserConfigDir()
+		default:
+			home, _ := os.UserHomeDir()
+			dir = filepath.Join(home, ".local", "state")
+		}
+	}
+
+	program, err := os.Executable()
+	if err != nil {
+		program = os.Args[0]
+	}
+	program = strings.TrimSuffix(filepath.Base(program), ".exe")
+
+	return filepath.Join(dir, "gexplorer", program+"-folders.json")
+}
+
+// loadFolders returns the last used folders stored in the given file, by purpose.
+func loadFolders(filename string) map[string]string {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+
+	var folders map[string]string
+	if err := json.Unmarshal(data, &folders); err != nil {
+		return nil
+	}
+	return folders
+}
+
+// storeFolder stores the given folder as the last one used for the given purpose in the given file.
+func storeFolder(filename, purpose, folder string) error {
+	foldersMutex.Lock()
+	defer foldersMutex.Unlock()
+
+	folders := loadFolders(filename)
+	if folders == nil {
+		if _, err := os.Stat(filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		folders = map[string]string{}
+	}
+
+	if folders[purpose] == folder {
+		return nil
+	}
+	folders[purpose] = folder
+
+	data, err := json.MarshalIndent(folders, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, append(data, '\n'))
+}
@@ -0,0 +1,296 @@
+// Package gexplorertest provides a scripted gexplorer backend, so the code using an Explorer
+// can be tested without desktop.
+//
+//	backend := gexplorertest.NewBackend(
+//		gexplorertest.Response{Paths: []string{"/tmp/data.csv"}},
+//		gexplorertest.Response{Cancel: true},
+//	)
+//	explorer := gexplorer.NewExplorerWithBackend(nil, backend)
+//
+// Each dialog consumes the next scripted response and is recorded, so the requested
+// extensions, names and options can be asserted using Backend.Calls.
+package gexplorertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mdouchement/gexplorer"
+)
+
+// ErrNoResponse is returned when a dialog is requested but no response is scripted.
+var ErrNoResponse = errors.New("gexplorertest: no response scripted")
+
+// Method is the name of the backend method called for a dialog.
+type Method string
+
+// Backend methods.
+const (
+	OpenFile      Method = "OpenFile"
+	OpenFiles     Method = "OpenFiles"
+	SaveFile      Method = "SaveFile"
+	SaveFiles     Method = "SaveFiles"
+	OpenDirectory Method = "OpenDirectory"
+	RevealFile    Method = "RevealFile"
+	OpenWith      Method = "OpenWith"
+)
+
+// Response is the scripted response of a dialog.
+type Response struct {
+	// Paths are the selected paths. They are returned as is, whatever the requested dialog.
+	Paths []string
+	// Filter is the filter picked by the user.
+	Filter *gexplorer.Filter
+	// Choices are the final values of the choices.
+	Choices map[string]string
+	// ReadOnly makes the selection not writable.
+	ReadOnly bool
+	// Cancel makes the dialog return gexplorer.ErrUserDecline, as if the user cancelled it.
+	Cancel bool
+	// Err is returned by the dialog when defined.
+	Err error
+	// Delay is the time the dialog takes to respond. The dialog is dismissed when the context is done before.
+	Delay time.Duration
+}
+
+// Call is a dialog requested to the backend.
+type Call struct {
+	// Method is the backend method called.
+	Method Method
+	// Options is a copy of the options of the dialog.
+	// The extensions are in Options.Extensions and the suggested name of a file in Options.Name.
+	Options gexplorer.Options
+	// Names are the names of the files to create, only used by SaveFiles.
+	Names []string
+	// Path is the file given to RevealFile and OpenWith.
+	Path string
+	// Ask reports whether OpenWith asked the user to choose the application.
+	Ask bool
+}
+
+// Backend is a gexplorer.Backend responding with scripted responses.
+// It's safe for concurrent use.
+type Backend struct {
+	mutex     sync.Mutex
+	responses []Response
+	calls     []Call
+
+	capabilities    gexplorer.Capabilities
+	capabilitiesErr error
+}
+
+// NewBackend returns a new Backend responding with the given responses, in order.
+func NewBackend(responses ...Response) *Backend {
+	return &Backend{
+		responses: responses,
+		capabilities: gexplorer.Capabilities{
+			OpenFile:        true,
+			OpenFiles:       true,
+			SaveFile:        true,
+			SaveFiles:       true,
+			OpenDirectory:   true,
+			OpenDirectories: true,
+			RevealFile:      true,
+			OpenWith:        true,
+			OpenWithChooser: true,
+			Filters:         true,
+			FilterSelection: true,
+			Choices:         true,
+			Folder:          true,
+			AcceptLabel:     true,
+			Modal:           true,
+		},
+	}
+}
+
+// NewExplorer returns a new gexplorer.Explorer using a Backend responding with the given responses.
+func NewExplorer(responses ...Response) (*gexplorer.Explorer, *Backend) {
+	backend := NewBackend(responses...)
+	return gexplorer.NewExplorerWithBackend(nil, backend), backend
+}
+
+// Script appends the given responses to the ones not yet consumed.
+func (b *Backend) Script(responses ...Response) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.responses = append(b.responses, responses...)
+}
+
+// Pending returns the number of responses not yet consumed.
+func (b *Backend) Pending() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return len(b.responses)
+}
+
+// Calls returns the dialogs requested so far, in order.
+func (b *Backend) Calls() []Call {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return append([]Call(nil), b.calls...)
+}
+
+// SetCapabilities sets the capabilities reported by the backend, everything is supported by default.
+// A non-nil error, such as gexplorer.ErrNotAvailable, is returned instead of the capabilities.
+func (b *Backend) SetCapabilities(capabilities gexplorer.Capabilities, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.capabilities = capabilities
+	b.capabilitiesErr = err
+}
+
+// Capabilities implements gexplorer.CapabilitiesReporter.
+func (b *Backend) Capabilities() (gexplorer.Capabilities, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.capabilitiesErr != nil {
+		return gexplorer.Capabilities{}, b.capabilitiesErr
+	}
+	return b.capabilities, nil
+}
+
+// OpenFile implements gexplorer.Backend.
+func (b *Backend) OpenFile(ctx context.Context, opts *gexplorer.Options) (*gexplorer.Selection, error) {
+	return b.respond(ctx, OpenFile, opts, nil)
+}
+
+// OpenFiles implements gexplorer.Backend.
+func (b *Backend) OpenFiles(ctx context.Context, opts *gexplorer.Options) (*gexplorer.Selection, error) {
+	return b.respond(ctx, OpenFiles, opts, nil)
+}
+
+// SaveFile implements gexplorer.Backend.
+func (b *Backend) SaveFile(ctx context.Context, opts *gexplorer.Options) (*gexplorer.Selection, error) {
+	return b.respond(ctx, SaveFile, opts, nil)
+}
+
+// SaveFiles implements gexplorer.FilesSaver.
+func (b *Backend) SaveFiles(ctx context.Context, opts *gexplorer.Options, names []string) (*gexplorer.Selection, error) {
+	return b.respond(ctx, SaveFiles, opts, names)
+}
+
+// OpenDirectory implements gexplorer.Backend.
+func (b *Backend) OpenDirectory(ctx context.Context, opts *gexplorer.Options) (*gexplorer.Selection, error) {
+	return b.respond(ctx, OpenDirectory, opts, nil)
+}
+
+// RevealFile implements gexplorer.FileRevealer.
+// It consumes a response, only its Err, Cancel and Delay fields are used.
+func (b *Backend) RevealFile(ctx context.Context, path string) error {
+	return b.launch(ctx, Call{Method: RevealFile, Path: path})
+}
+
+// OpenWith implements gexplorer.FileOpener.
+// It consumes a response like RevealFile.
+func (b *Backend) OpenWith(ctx context.Context, path string, ask bool) error {
+	return b.launch(ctx, Call{Method: OpenWith, Path: path, Ask: ask})
+}
+
+// launch records the call and responds with the error of the next scripted response.
+func (b *Backend) launch(ctx context.Context, call Call) error {
+	response, err := b.next(ctx, call)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case response.Err != nil:
+		return response.Err
+	case response.Cancel:
+		return gexplorer.ErrUserDecline
+	}
+	return nil
+}
+
+// respond records the call and responds with the next scripted response.
+func (b *Backend) respond(ctx context.Context, method Method, opts *gexplorer.Options, names []string) (*gexplorer.Selection, error) {
+	response, err := b.next(ctx, Call{
+		Method:  method,
+		Options: cloneOptions(opts),
+		Names:   slices.Clone(names),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case response.Err != nil:
+		return nil, response.Err
+	case response.Cancel || len(response.Paths) == 0:
+		return nil, gexplorer.ErrUserDecline
+	}
+
+	selection := gexplorer.NewSelection(slices.Clone(response.Paths))
+	selection.Filter = response.Filter
+	selection.Choices = response.Choices
+	selection.Writable = !response.ReadOnly
+
+	return selection, nil
+}
+
+// cloneOptions returns a copy of the given options not sharing their slices, so the recorded
+// calls aren't changed by the code under test.
+func cloneOptions(opts *gexplorer.Options) gexplorer.Options {
+	o := *opts
+	o.Extensions = slices.Clone(opts.Extensions)
+	o.Filters = slices.Clone(opts.Filters)
+	for i, filter := range o.Filters {
+		o.Filters[i] = cloneFilter(filter)
+	}
+	if opts.CurrentFilter != nil {
+		filter := cloneFilter(*opts.CurrentFilter)
+		o.CurrentFilter = &filter
+	}
+	o.Choices = slices.Clone(opts.Choices)
+	for i, choice := range o.Choices {
+		o.Choices[i].Options = slices.Clone(choice.Options)
+	}
+	return o
+}
+
+// cloneFilter returns a copy of the given filter not sharing its slices.
+func cloneFilter(filter gexplorer.Filter) gexplorer.Filter {
+	filter.Patterns = slices.Clone(filter.Patterns)
+	filter.MIMETypes = slices.Clone(filter.MIMETypes)
+	return filter
+}
+
+// next records the given call and returns the next scripted response, once its delay is elapsed.
+func (b *Backend) next(ctx context.Context, call Call) (Response, error) {
+	b.mutex.Lock()
+	b.calls = append(b.calls, call)
+
+	if len(b.responses) == 0 {
+		b.mutex.Unlock()
+		return Response{}, fmt.Errorf("%w for %s", ErrNoResponse, call.Method)
+	}
+
+	response := b.responses[0]
+	b.responses = b.responses[1:]
+	b.mutex.Unlock()
+
+	if response.Delay > 0 {
+		timer := time.NewTimer(response.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return Response{}, ctx.Err()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+
+	return response, nil
+}
@@ -1,11 +1,14 @@
 package gioexplorer
 
 import (
+	"context"
 	"io"
+	"io/fs"
 	"os"
 
 	"gioui.org/app"
 	"gioui.org/io/event"
+	"github.com/mdouchement/gexplorer"
 )
 
 // Explorer facilitates opening OS-native dialogs to choose files and create files.
@@ -24,6 +27,15 @@ func NewExplorer(w *app.Window) (e *Explorer) {
 	}
 }
 
+// NewExplorerWithBackend creates a new Explorer for the given *app.Window, showing the dialogs
+// of the given backend (such as the one of the gexplorertest package).
+// The window may be nil when the backend doesn't need it, such as in tests.
+func NewExplorerWithBackend(w *app.Window, backend gexplorer.Backend) *Explorer {
+	return &Explorer{
+		explorer: newExplorerWithBackend(w, backend),
+	}
+}
+
 // ListenEvents must get all the events from Gio, in order to get the GioView. You must
 // include that function where you listen for Gio events.
 //
@@ -44,6 +56,60 @@ func (e *Explorer) ListenEvents(evt event.Event) {
 	e.listenEvents(evt)
 }
 
+// Close releases the resources held by the Explorer, such as the D-Bus connection on Linux.
+// The Explorer can still be used afterwards.
+func (e *Explorer) Close() error {
+	if e == nil {
+		return nil
+	}
+	return e.gexplorer.Close()
+}
+
+// Capabilities returns which dialogs and options the backend of the Explorer supports.
+// ErrNotAvailable is returned when no dialog can be shown.
+func (e *Explorer) Capabilities() (gexplorer.Capabilities, error) {
+	if e == nil {
+		return gexplorer.Capabilities{}, gexplorer.ErrNotAvailable
+	}
+	return e.gexplorer.Capabilities()
+}
+
+// RevealFile opens the directory containing the given file in the file manager, the file being selected.
+// It's useful to show where a file has been created, such as after CreateFile.
+func (e *Explorer) RevealFile(path string) error {
+	if e == nil {
+		return gexplorer.ErrNotAvailable
+	}
+	return e.gexplorer.RevealFile(path)
+}
+
+// OpenWith opens the given file with its default application, such as a PDF viewer for an exported PDF.
+// When askUser is set, the user chooses the application (the "Open With…" dialog).
+func (e *Explorer) OpenWith(path string, askUser bool) error {
+	if e == nil {
+		return gexplorer.ErrNotAvailable
+	}
+	return e.gexplorer.OpenWith(path, askUser)
+}
+
+// SetRecentFiles makes the Explorer record the files chosen and created with its dialogs in the
+// recently used files of the desktop, as the given application. It's disabled when the name is empty.
+func (e *Explorer) SetRecentFiles(application string) {
+	if e == nil {
+		return
+	}
+	e.gexplorer.SetRecentFiles(application)
+}
+
+// Recent returns the recently used files, the most recent first.
+// When the Explorer records its files (see SetRecentFiles), only the ones of its application are returned.
+func (e *Explorer) Recent() ([]gexplorer.RecentFile, error) {
+	if e == nil {
+		return nil, gexplorer.ErrNotAvailable
+	}
+	return e.gexplorer.Recent()
+}
+
 // ChooseFile shows the file selector, allowing the user to select a single file.
 // Optionally, it's possible to define which file extensions is supported to
 // be selected (such as `.jpg`, `.png`).
@@ -56,7 +122,13 @@ func (e *Explorer) ListenEvents(evt event.Event) {
 // It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
 // ChooseFile or CreateFile, can happen at the same time, for each app.Window/Explorer.
 func (e *Explorer) ChooseFile(extensions ...string) (string, error) {
-	return e.importFile(extensions...)
+	return e.ChooseFileContext(context.Background(), extensions...)
+}
+
+// ChooseFileContext is like ChooseFile but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) ChooseFileContext(ctx context.Context, extensions ...string) (string, error) {
+	return e.importFile(ctx, extensions...)
 }
 
 // ChooseFileIO shows the file selector, allowing the user to select a single file.
@@ -82,6 +154,13 @@ func (e *Explorer) ChooseFileIO(extensions ...string) (io.ReadCloser, error) {
 	return os.Open(filename)
 }
 
+// ChooseFileAsync is like ChooseFileContext but it returns immediately.
+// The result is delivered by the returned Handle and, when not nil, given to the callback
+// which is called on the event loop of the app.Window.
+func (e *Explorer) ChooseFileAsync(ctx context.Context, callback func(gexplorer.Result), extensions ...string) *gexplorer.Handle {
+	return e.gexplorer.ChooseFileAsync(ctx, callback, extensions...)
+}
+
 // ChooseFiles shows the files selector, allowing the user to select multiple files.
 // Optionally, it's possible to define which file extensions is supported to
 // be selected (such as `.jpg`, `.png`).
@@ -94,7 +173,19 @@ func (e *Explorer) ChooseFileIO(extensions ...string) (io.ReadCloser, error) {
 // It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
 // ChooseFile{,s} or CreateFile, can happen at the same time, for each app.Window/Explorer.
 func (e *Explorer) ChooseFiles(extensions ...string) ([]string, error) {
-	return e.importFiles(extensions...)
+	return e.ChooseFilesContext(context.Background(), extensions...)
+}
+
+// ChooseFilesContext is like ChooseFiles but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) ChooseFilesContext(ctx context.Context, extensions ...string) ([]string, error) {
+	return e.importFiles(ctx, extensions...)
+}
+
+// ChooseFilesAsync is like ChooseFilesContext but it returns immediately.
+// The result is delivered like ChooseFileAsync.
+func (e *Explorer) ChooseFilesAsync(ctx context.Context, callback func(gexplorer.Result), extensions ...string) *gexplorer.Handle {
+	return e.gexplorer.ChooseFilesAsync(ctx, callback, extensions...)
 }
 
 // ChooseFilesIO shows the files selector, allowing the user to select multiple files.
@@ -136,7 +227,19 @@ func (e *Explorer) ChooseFilesIO(extensions ...string) ([]io.ReadCloser, error)
 // It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
 // ChooseFile or CreateFile, can happen at the same time, for each Explorer.
 func (e *Explorer) CreateFile(name string) (string, error) {
-	return e.exportFile(name)
+	return e.CreateFileContext(context.Background(), name)
+}
+
+// CreateFileContext is like CreateFile but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) CreateFileContext(ctx context.Context, name string) (string, error) {
+	return e.exportFile(ctx, name)
+}
+
+// CreateFileAsync is like CreateFileContext but it returns immediately.
+// The result is delivered like ChooseFileAsync.
+func (e *Explorer) CreateFileAsync(ctx context.Context, name string, callback func(gexplorer.Result)) *gexplorer.Handle {
+	return e.gexplorer.CreateFileAsync(ctx, name, callback)
 }
 
 // CreateFileIO opens the file selector, and writes the given content into
@@ -158,3 +261,114 @@ func (e *Explorer) CreateFileIO(name string) (io.WriteCloser, error) {
 
 	return os.Create(filename)
 }
+
+// CreateFiles opens the directory selector, allowing the user to choose where
+// the files with the given names are created. It returns the path of each file,
+// in the same order as the given names.
+//
+// It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
+// dialog can happen at the same time, for each app.Window/Explorer.
+func (e *Explorer) CreateFiles(names ...string) ([]string, error) {
+	return e.CreateFilesContext(context.Background(), names...)
+}
+
+// CreateFilesContext is like CreateFiles but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) CreateFilesContext(ctx context.Context, names ...string) ([]string, error) {
+	return e.exportFiles(ctx, names...)
+}
+
+// CreateFilesIO opens the directory selector, allowing the user to choose where
+// the files with the given names are created. It returns a writer for each file,
+// in the same order as the given names.
+//
+// It's important to close each `io.WriteCloser`. In some platforms the
+// file will be saved only when the writer is closer.
+//
+// In some platforms the resulting `io.WriteCloser` are `os.File`, but it's not
+// a guarantee.
+//
+// It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
+// dialog can happen at the same time, for each app.Window/Explorer.
+func (e *Explorer) CreateFilesIO(names ...string) ([]io.WriteCloser, error) {
+	filenames, err := e.CreateFiles(names...)
+	if err != nil {
+		return nil, err
+	}
+
+	writers := make([]io.WriteCloser, len(filenames))
+	for i, filename := range filenames {
+		f, err := os.Create(filename)
+		if err != nil {
+			for _, w := range writers[:i] {
+				w.Close()
+			}
+			return nil, err
+		}
+
+		writers[i] = f
+	}
+
+	return writers, nil
+}
+
+// ChooseDirectory shows the directory selector, allowing the user to select a single directory.
+//
+// It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
+// ChooseFile{,s}, ChooseDirector{y,ies} or CreateFile, can happen at the same time, for each app.Window/Explorer.
+func (e *Explorer) ChooseDirectory() (string, error) {
+	return e.ChooseDirectoryContext(context.Background())
+}
+
+// ChooseDirectoryContext is like ChooseDirectory but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) ChooseDirectoryContext(ctx context.Context) (string, error) {
+	return e.importDirectory(ctx)
+}
+
+// ChooseDirectoryFS shows the directory selector, allowing the user to select a single directory.
+// The resulting `fs.FS` is rooted at the selected directory.
+//
+// It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
+// ChooseFile{,s}, ChooseDirector{y,ies} or CreateFile, can happen at the same time, for each app.Window/Explorer.
+func (e *Explorer) ChooseDirectoryFS() (fs.FS, error) {
+	dirname, err := e.ChooseDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	return os.DirFS(dirname), nil
+}
+
+// ChooseDirectories shows the directory selector, allowing the user to select multiple directories.
+//
+// It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
+// ChooseFile{,s}, ChooseDirector{y,ies} or CreateFile, can happen at the same time, for each app.Window/Explorer.
+func (e *Explorer) ChooseDirectories() ([]string, error) {
+	return e.ChooseDirectoriesContext(context.Background())
+}
+
+// ChooseDirectoriesContext is like ChooseDirectories but the dialog is dismissed when the given context is done.
+// In that case, the context's error is returned.
+func (e *Explorer) ChooseDirectoriesContext(ctx context.Context) ([]string, error) {
+	return e.importDirectories(ctx)
+}
+
+// ChooseDirectoriesFS shows the directory selector, allowing the user to select multiple directories.
+// Each resulting `fs.FS` is rooted at one of the selected directories.
+//
+// It's a blocking call, you should call it on a separated goroutine. For most OSes, only one
+// ChooseFile{,s}, ChooseDirector{y,ies} or CreateFile, can happen at the same time, for each app.Window/Explorer.
+func (e *Explorer) ChooseDirectoriesFS() ([]fs.FS, error) {
+	dirnames, err := e.ChooseDirectories()
+	if err != nil {
+		return nil, err
+	}
+
+	fsyss := make([]fs.FS, len(dirnames))
+	for i, dirname := range dirnames {
+		fsyss[i] = os.DirFS(dirname)
+	}
+
+	return fsyss, nil
+}
@@ -11,6 +11,8 @@ package gioexplorer
 import "C"
 
 import (
+	"context"
+
 	"gioui.org/app"
 	"gioui.org/io/event"
 	"github.com/mdouchement/gexplorer"
@@ -28,6 +30,18 @@ func newExplorer(w *app.Window) *explorer {
 	}
 }
 
+func newExplorerWithBackend(w *app.Window, backend gexplorer.Backend) *explorer {
+	var run gexplorer.RunHandler
+	if w != nil {
+		run = w.Run
+	}
+
+	return &explorer{
+		window:    w,
+		gexplorer: gexplorer.NewExplorerWithBackend(run, backend),
+	}
+}
+
 func (e *explorer) listenEvents(event event.Event) {
 	switch event := event.(type) {
 	case app.ViewEvent:
@@ -35,14 +49,26 @@ func (e *explorer) listenEvents(event event.Event) {
 	}
 }
 
-func (e *explorer) importFile(extensions ...string) (string, error) {
-	return e.gexplorer.ChooseFile(extensions...)
+func (e *explorer) importFile(ctx context.Context, extensions ...string) (string, error) {
+	return e.gexplorer.ChooseFileContext(ctx, extensions...)
+}
+
+func (e *explorer) importFiles(ctx context.Context, extensions ...string) ([]string, error) {
+	return e.gexplorer.ChooseFilesContext(ctx, extensions...)
+}
+
+func (e *explorer) importDirectory(ctx context.Context) (string, error) {
+	return e.gexplorer.ChooseDirectoryContext(ctx)
+}
+
+func (e *explorer) importDirectories(ctx context.Context) ([]string, error) {
+	return e.gexplorer.ChooseDirectoriesContext(ctx)
 }
 
-func (e *explorer) importFiles(extensions ...string) ([]string, error) {
-	return e.gexplorer.ChooseFiles(extensions...)
+func (e *explorer) exportFile(ctx context.Context, name string) (string, error) {
+	return e.gexplorer.CreateFileContext(ctx, name)
 }
 
-func (e *explorer) exportFile(name string) (string, error) {
-	return e.gexplorer.CreateFile(name)
+func (e *explorer) exportFiles(ctx context.Context, names ...string) ([]string, error) {
+	return e.gexplorer.CreateFilesContext(ctx, names...)
 }
@@ -4,28 +4,56 @@
 package gioexplorer
 
 import (
-	"io"
+	"context"
 
 	"gioui.org/app"
 	"gioui.org/io/event"
+	"github.com/mdouchement/gexplorer"
 )
 
-type explorer struct{}
+type explorer struct {
+	gexplorer *gexplorer.Explorer
+}
 
 func newExplorer(w *app.Window) *explorer {
-	return new(explorer)
+	return &explorer{
+		gexplorer: gexplorer.NewExplorer(w.Run),
+	}
+}
+
+func newExplorerWithBackend(w *app.Window, backend gexplorer.Backend) *explorer {
+	var run gexplorer.RunHandler
+	if w != nil {
+		run = w.Run
+	}
+
+	return &explorer{
+		gexplorer: gexplorer.NewExplorerWithBackend(run, backend),
+	}
+}
+
+func (e *explorer) listenEvents(_ event.Event) {}
+
+func (e *explorer) importFile(ctx context.Context, extensions ...string) (string, error) {
+	return e.gexplorer.ChooseFileContext(ctx, extensions...)
 }
 
-func (e *Explorer) listenEvents(_ event.Event) {}
+func (e *explorer) importFiles(ctx context.Context, extensions ...string) ([]string, error) {
+	return e.gexplorer.ChooseFilesContext(ctx, extensions...)
+}
+
+func (e *explorer) importDirectory(ctx context.Context) (string, error) {
+	return e.gexplorer.ChooseDirectoryContext(ctx)
+}
 
-func (e *Explorer) exportFile(_ string) (string, error) {
-	return nil, ErrNotAvailable
+func (e *explorer) importDirectories(ctx context.Context) ([]string, error) {
+	return e.gexplorer.ChooseDirectoriesContext(ctx)
 }
 
-func (e *Explorer) importFile(_ ...string) (string, error) {
-	return nil, ErrNotAvailable
+func (e *explorer) exportFile(ctx context.Context, name string) (string, error) {
+	return e.gexplorer.CreateFileContext(ctx, name)
 }
 
-func (e *Explorer) importFiles(_ ...string) ([]string, error) {
-	return nil, ErrNotAvailable
+func (e *explorer) exportFiles(ctx context.Context, names ...string) ([]string, error) {
+	return e.gexplorer.CreateFilesContext(ctx, names...)
 }
@@ -1,6 +1,8 @@
 package gioexplorer
 
 import (
+	"context"
+
 	"gioui.org/app"
 	"gioui.org/io/event"
 	"github.com/mdouchement/gexplorer"
@@ -10,9 +12,20 @@ type explorer struct {
 	gexplorer *gexplorer.Explorer
 }
 
-func newExplorer(_ *app.Window) *explorer {
+func newExplorer(w *app.Window) *explorer {
+	return &explorer{
+		gexplorer: gexplorer.NewExplorer(w.Run),
+	}
+}
+
+func newExplorerWithBackend(w *app.Window, backend gexplorer.Backend) *explorer {
+	var run gexplorer.RunHandler
+	if w != nil {
+		run = w.Run
+	}
+
 	return &explorer{
-		gexplorer: gexplorer.NewExplorer(nil),
+		gexplorer: gexplorer.NewExplorerWithBackend(run, backend),
 	}
 }
 
@@ -20,14 +33,26 @@ func (e *explorer) listenEvents(_ event.Event) {
 	// NO-OP
 }
 
-func (e *explorer) importFile(extensions ...string) (string, error) {
-	return e.gexplorer.ChooseFile(extensions...)
+func (e *explorer) importFile(ctx context.Context, extensions ...string) (string, error) {
+	return e.gexplorer.ChooseFileContext(ctx, extensions...)
+}
+
+func (e *explorer) importFiles(ctx context.Context, extensions ...string) ([]string, error) {
+	return e.gexplorer.ChooseFilesContext(ctx, extensions...)
+}
+
+func (e *explorer) importDirectory(ctx context.Context) (string, error) {
+	return e.gexplorer.ChooseDirectoryContext(ctx)
+}
+
+func (e *explorer) importDirectories(ctx context.Context) ([]string, error) {
+	return e.gexplorer.ChooseDirectoriesContext(ctx)
 }
 
-func (e *explorer) importFiles(extensions ...string) ([]string, error) {
-	return e.gexplorer.ChooseFiles(extensions...)
+func (e *explorer) exportFile(ctx context.Context, name string) (string, error) {
+	return e.gexplorer.CreateFileContext(ctx, name)
 }
 
-func (e *explorer) exportFile(name string) (string, error) {
-	return e.gexplorer.CreateFile(name)
+func (e *explorer) exportFiles(ctx context.Context, names ...string) ([]string, error) {
+	return e.gexplorer.CreateFilesContext(ctx, names...)
 }
@@ -0,0 +1,46 @@
+package gexplorer
+
+import "context"
+
+// RevealFile opens the directory containing the given file in the file manager, the file being selected.
+// It's useful to show where a file has been created, such as after CreateFile.
+//
+// ErrNotAvailable is returned when the backend doesn't implement FileRevealer.
+func (e *Explorer) RevealFile(path string) error {
+	return e.RevealFileContext(context.Background(), path)
+}
+
+// RevealFileContext is like RevealFile but it's given up when the given context is done.
+func (e *Explorer) RevealFileContext(ctx context.Context, path string) error {
+	if e == nil {
+		return ErrNotAvailable
+	}
+
+	revealer, ok := e.backend.(FileRevealer)
+	if !ok {
+		return ErrNotAvailable
+	}
+	return revealer.RevealFile(ctx, path)
+}
+
+// OpenWith opens the given file with its default application, such as a PDF viewer for an exported PDF.
+// When askUser is set, the user chooses the application (the "Open With…" dialog).
+//
+// ErrNotAvailable is returned when the backend doesn't implement FileOpener,
+// or when it can't ask the user.
+func (e *Explorer) OpenWith(path string, askUser bool) error {
+	return e.OpenWithContext(context.Background(), path, askUser)
+}
+
+// OpenWithContext is like OpenWith but it's given up when the given context is done.
+func (e *Explorer) OpenWithContext(ctx context.Context, path string, askUser bool) error {
+	if e == nil {
+		return ErrNotAvailable
+	}
+
+	opener, ok := e.backend.(FileOpener)
+	if !ok {
+		return ErrNotAvailable
+	}
+	return opener.OpenWith(ctx, path, askUser)
+}
@@ -0,0 +1,454 @@
+package gexplorer
+
+import (
+	"bufio"
+	"encoding/xml"
+	"mime"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//
+// https://specifications.freedesktop.org/shared-mime-info-spec/shared-mime-info-spec-latest.html
+//
+
+// mimeGlob is a glob pattern of the shared-mime-info database.
+type mimeGlob struct {
+	weight        int
+	mimeType      string
+	pattern       string
+	caseSensitive bool
+}
+
+// mimeDatabase holds the globs, aliases and subclasses of the shared-mime-info database.
+type mimeDatabase struct {
+	globs      []mimeGlob          // By order of precedence.
+	extensions map[string]int      // The index of the first case-insensitive `*.ext` glob of each lower cased extension.
+	patterns   []int               // The indexes of the other globs.
+	aliases    map[string]string   // The canonical type of each alias.
+	subclasses map[string][]string // The parent types of each type.
+}
+
+// sharedMIMEInfo returns the shared-mime-info database of the system, loaded once.
+// It's empty when the database isn't installed (such as on Windows and macOS).
+var sharedMIMEInfo = sync.OnceValue(func() *mimeDatabase {
+	return loadMIMEDatabase(mimeDirectories())
+})
+
+// mimeComments returns the descriptions of the MIME types by language (the unlocalized one
+// being the empty language), loaded once from the source packages of the shared-mime-info database.
+// Only the languages of the user are kept.
+var mimeComments = sync.OnceValue(func() map[string]map[string]string {
+	return loadMIMEComments(mimeDirectories(), userLanguages())
+})
+
+// mimeDirectories returns the `mime` directories of the XDG data directories, by order of precedence.
+func mimeDirectories() []string {
+	var dirs []string
+
+	home := os.Getenv("XDG_DATA_HOME")
+	if home == "" {
+		if dir, err := os.UserHomeDir(); err == nil {
+			home = filepath.Join(dir, ".local", "share")
+		}
+	}
+	if home != "" {
+		dirs = append(dirs, home)
+	}
+
+	data := os.Getenv("XDG_DATA_DIRS")
+	if data == "" && runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		data = "/usr/local/share:/usr/share"
+	}
+	for _, dir := range filepath.SplitList(data) {
+		if dir != "" && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	for i, dir := range dirs {
+		dirs[i] = filepath.Join(dir, "mime")
+	}
+	return dirs
+}
+
+// loadMIMEDatabase loads the globs2, aliases and subclasses files of the given directories.
+// The globs of a directory without globs2 file, whose database hasn't been compiled, are read from its packages.
+func loadMIMEDatabase(dirs []string) *mimeDatabase {
+	db := &mimeDatabase{
+		aliases:    map[string]string{},
+		subclasses: map[string][]string{},
+	}
+
+	for _, dir := range dirs {
+		globs, err := readGlobs2(filepath.Join(dir, "globs2"))
+		if os.IsNotExist(err) {
+			globs = readPackageGlobs(filepath.Join(dir, "packages"))
+		}
+		db.globs = append(db.globs, globs...)
+
+		readAliases(filepath.Join(dir, "aliases"), db.aliases)
+		readSubclasses(filepath.Join(dir, "subclasses"), db.subclasses)
+	}
+
+	// The highest weights first then the longest patterns, the first directories having the precedence.
+	slices.SortStableFunc(db.globs, func(a, b mimeGlob) int {
+		if a.weight != b.weight {
+			return b.weight - a.weight
+		}
+		return len(b.pattern) - len(a.pattern)
+	})
+
+	db.index()
+	return db
+}
+
+// index splits the globs between the simple extensions (such as `*.png`), looked up by map, and the other patterns.
+func (db *mimeDatabase) index() {
+	db.extensions = map[string]int{}
+	for i, glob := range db.globs {
+		ext, ok := strings.CutPrefix(glob.pattern, "*")
+		if !ok || !strings.HasPrefix(ext, ".") || glob.caseSensitive || strings.ContainsAny(ext, "*?[\\") {
+			db.patterns = append(db.patterns, i)
+			continue
+		}
+
+		ext = strings.ToLower(ext)
+		if _, ok := db.extensions[ext]; !ok {
+			db.extensions[ext] = i
+		}
+	}
+}
+
+// lookup returns the glob of highest precedence matching the given filename.
+func (db *mimeDatabase) lookup(name string) (mimeGlob, bool) {
+	base := filepath.Base(name)
+	lower := strings.ToLower(base)
+
+	// Each suffix starting with a dot (such as `.tar.gz` then `.gz`).
+	best := -1
+	for i := range len(lower) {
+		if lower[i] != '.' {
+			continue
+		}
+		if j, ok := db.extensions[lower[i:]]; ok && (best < 0 || j < best) {
+			best = j
+		}
+	}
+
+	for _, i := range db.patterns {
+		if best >= 0 && i > best {
+			break
+		}
+
+		glob := db.globs[i]
+		if glob.caseSensitive {
+			if ok, _ := filepath.Match(glob.pattern, base); ok {
+				best = i
+				break
+			}
+			continue
+		}
+		if ok, _ := filepath.Match(strings.ToLower(glob.pattern), lower); ok {
+			best = i
+			break
+		}
+	}
+
+	if best < 0 {
+		return mimeGlob{}, false
+	}
+	return db.globs[best], true
+}
+
+// readGlobs2 reads the globs of the given globs2 file, whose lines are `weight:type:pattern[:flags]`.
+func readGlobs2(filename string) ([]mimeGlob, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var globs []mimeGlob
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, ":", 4)
+		if len(fields) < 3 {
+			continue
+		}
+
+		weight, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		glob := mimeGlob{weight: weight, mimeType: fields[1], pattern: fields[2]}
+		if len(fields) == 4 {
+			glob.caseSensitive = slices.Contains(strings.Split(fields[3], ","), "cs")
+		}
+		globs = append(globs, glob)
+	}
+
+	return globs, scanner.Err()
+}
+
+// readAliases reads the given aliases file, whose lines are `alias type`.
+func readAliases(filename string, aliases map[string]string) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		alias, mimeType, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || strings.HasPrefix(alias, "#") {
+			continue
+		}
+		if _, ok := aliases[alias]; !ok {
+			aliases[alias] = mimeType
+		}
+	}
+}
+
+// readSubclasses reads the given subclasses file, whose lines are `type parent`.
+func readSubclasses(filename string, subclasses map[string][]string) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		mimeType, parent, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || strings.HasPrefix(mimeType, "#") {
+			continue
+		}
+		subclasses[mimeType] = appendUnique(subclasses[mimeType], parent)
+	}
+}
+
+// mimePackageType is a mime-type element of a package of the database.
+type mimePackageType struct {
+	Type     string `xml:"type,attr"`
+	Comments []struct {
+		Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"comment"`
+	Globs []struct {
+		Pattern       string `xml:"pattern,attr"`
+		Weight        string `xml:"weight,attr"`
+		CaseSensitive string `xml:"case-sensitive,attr"`
+	} `xml:"glob"`
+}
+
+// readPackages calls the given function for each mime-type element of the packages of the given directory.
+func readPackages(dir string, fn func(mimeType *mimePackageType)) {
+	filenames, _ := filepath.Glob(filepath.Join(dir, "*.xml"))
+	slices.Sort(filenames)
+
+	for _, filename := range filenames {
+		f, err := os.Open(filename)
+		if err != nil {
+			continue
+		}
+
+		decoder := xml.NewDecoder(bufio.NewReader(f))
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break // io.EOF or an invalid package, the parsed types are kept.
+			}
+
+			start, ok := token.(xml.StartElement)
+			if !ok || start.Name.Local != "mime-type" {
+				continue
+			}
+
+			var mimeType mimePackageType
+			if err := decoder.DecodeElement(&mimeType, &start); err != nil {
+				break
+			}
+			fn(&mimeType)
+		}
+
+		f.Close()
+	}
+}
+
+// readPackageGlobs reads the globs of the packages of the given directory.
+func readPackageGlobs(dir string) []mimeGlob {
+	var globs []mimeGlob
+	readPackages(dir, func(mimeType *mimePackageType) {
+		for _, g := range mimeType.Globs {
+			glob := mimeGlob{weight: 50, mimeType: mimeType.Type, pattern: g.Pattern, caseSensitive: g.CaseSensitive == "true"}
+			if weight, err := strconv.Atoi(g.Weight); err == nil {
+				glob.weight = weight
+			}
+			globs = append(globs, glob)
+		}
+	})
+	return globs
+}
+
+// loadMIMEComments loads the descriptions of the MIME types from the packages of the given directories,
+// in the given languages and unlocalized.
+func loadMIMEComments(dirs []string, languages []string) map[string]map[string]string {
+	comments := map[string]map[string]string{}
+	for _, dir := range dirs {
+		readPackages(filepath.Join(dir, "packages"), func(mimeType *mimePackageType) {
+			for _, comment := range mimeType.Comments {
+				if comment.Lang != "" && !slices.Contains(languages, comment.Lang) {
+					continue
+				}
+				if comments[mimeType.Type] == nil {
+					comments[mimeType.Type] = map[string]string{}
+				}
+				if _, ok := comments[mimeType.Type][comment.Lang]; !ok {
+					comments[mimeType.Type][comment.Lang] = strings.TrimSpace(comment.Text)
+				}
+			}
+		})
+	}
+	return comments
+}
+
+// canonicalMIMEType returns the canonical form of the given MIME type, resolving its alias if any.
+func canonicalMIMEType(mt string) string {
+	if canonical, ok := sharedMIMEInfo().aliases[mt]; ok {
+		return canonical
+	}
+	return mt
+}
+
+// isA reports whether the given MIME type is the given parent type or one of its subclasses,
+// such as `image/svg+xml` for `application/xml`.
+func isA(mt, parent string) bool {
+	db := sharedMIMEInfo()
+	mt, parent = canonicalMIMEType(mt), canonicalMIMEType(parent)
+
+	seen := map[string]bool{}
+	types := []string{mt}
+	for len(types) > 0 {
+		mt, types = types[0], types[1:]
+		if mt == parent {
+			return true
+		}
+		if seen[mt] {
+			continue
+		}
+		seen[mt] = true
+
+		for _, p := range db.subclasses[mt] {
+			types = append(types, canonicalMIMEType(p))
+		}
+	}
+	return false
+}
+
+// typeByFilename returns the MIME type of the given filename, empty when unknown.
+// The shared-mime-info database is used first, then the mime package.
+func typeByFilename(name string) string {
+	if glob, ok := sharedMIMEInfo().lookup(name); ok {
+		return glob.mimeType
+	}
+
+	mt, _, err := mime.ParseMediaType(mime.TypeByExtension(strings.ToLower(filepath.Ext(name))))
+	if err != nil {
+		return ""
+	}
+	return canonicalMIMEType(mt)
+}
+
+// extensionsByType returns the extensions of the given MIME type, from the shared-mime-info database and the mime package.
+// A wildcard subtype (such as `image/*`) matches all the types of the media type, the common ones being used
+// when the database isn't available.
+func extensionsByType(mt string) []string {
+	mt = canonicalMIMEType(mt)
+
+	var extensions []string
+	for _, glob := range sharedMIMEInfo().globs {
+		ext, ok := strings.CutPrefix(glob.pattern, "*")
+		if !ok || !strings.HasPrefix(ext, ".") || strings.ContainsAny(ext, "*?[") || !matchMIMEType(mt, glob.mimeType) {
+			continue
+		}
+		extensions = appendUnique(extensions, strings.ToLower(ext))
+	}
+
+	major, wildcard := strings.CutSuffix(mt, "/*")
+	if !wildcard {
+		exts, _ := mime.ExtensionsByType(mt)
+		for _, ext := range exts {
+			extensions = appendUnique(extensions, strings.ToLower(ext))
+		}
+		return extensions
+	}
+
+	if len(extensions) == 0 {
+		for media, exts := range commonExtensions {
+			if major == "*" || major == media {
+				extensions = append(extensions, exts...)
+			}
+		}
+	}
+	slices.Sort(extensions)
+	return extensions
+}
+
+// mimeTypeComment returns the description of the given MIME type in the language of the user
+// (such as "PNG image"), empty when unknown.
+func mimeTypeComment(mt string) string {
+	comments := mimeComments()[canonicalMIMEType(mt)]
+	if len(comments) == 0 {
+		return ""
+	}
+
+	for _, lang := range userLanguages() {
+		if comment, ok := comments[lang]; ok {
+			return comment
+		}
+	}
+	return comments[""]
+}
+
+// userLanguages returns the languages of the user by order of preference (such as `fr_FR` then `fr`),
+// from the locale environment variables.
+func userLanguages() []string {
+	var locale string
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale = os.Getenv(name); locale != "" {
+			break
+		}
+	}
+
+	// Such as `fr_FR.UTF-8@euro`.
+	locale, modifier, _ := strings.Cut(locale, "@")
+	locale, _, _ = strings.Cut(locale, ".")
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return nil
+	}
+
+	lang, _, _ := strings.Cut(locale, "_")
+
+	var languages []string
+	if modifier != "" {
+		languages = append(languages, locale+"@"+modifier)
+	}
+	languages = appendUnique(languages, locale)
+	if modifier != "" {
+		languages = appendUnique(languages, lang+"@"+modifier)
+	}
+	return appendUnique(languages, lang)
+}
@@ -0,0 +1,250 @@
+package gexplorer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mimeFixture is a shared-mime-info database of a data directory, by file relative to its `mime` directory.
+type mimeFixture map[string]string
+
+// useMIMEDatabase makes the tests use the given databases, the first one being `$XDG_DATA_HOME`
+// and the others `$XDG_DATA_DIRS`. The database loaded once is reset.
+// It returns the data directory of each database.
+func useMIMEDatabase(t *testing.T, fixtures ...mimeFixture) []string {
+	t.Helper()
+
+	var dirs []string
+	for _, fixture := range fixtures {
+		dir := t.TempDir()
+		for name, content := range fixture {
+			filename := filepath.Join(dir, "mime", filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) == 0 {
+		dirs = append(dirs, t.TempDir())
+	}
+
+	t.Setenv("XDG_DATA_HOME", dirs[0])
+	t.Setenv("XDG_DATA_DIRS", strings.Join(append(dirs[1:], t.TempDir()), string(filepath.ListSeparator)))
+
+	database, comments := sharedMIMEInfo, mimeComments
+	t.Cleanup(func() {
+		sharedMIMEInfo, mimeComments = database, comments
+	})
+	sharedMIMEInfo = sync.OnceValue(func() *mimeDatabase {
+		return loadMIMEDatabase(mimeDirectories())
+	})
+	mimeComments = sync.OnceValue(func() map[string]map[string]string {
+		return loadMIMEComments(mimeDirectories(), userLanguages())
+	})
+	return dirs
+}
+
+// setLanguage sets the locale of the user.
+func setLanguage(t *testing.T, lang string) {
+	t.Helper()
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", lang)
+}
+
+// userMIMEFixture is the database of the user, taking precedence over the system ones.
+var userMIMEFixture = mimeFixture{
+	"globs2": "# weight:type:pattern\n" +
+		"50:text/x-user-csv:*.csv\n",
+}
+
+// systemMIMEFixture is a compiled system database.
+var systemMIMEFixture = mimeFixture{
+	"globs2": "# weight:type:pattern[:flags]\n" +
+		"50:text/csv:*.csv\n" +
+		"50:text/plain:*.txt\n" +
+		"50:application/gzip:*.gz\n" +
+		"50:application/x-compressed-tar:*.tar.gz\n" +
+		"20:image/x-low:*.png\n" +
+		"80:image/png:*.png\n" +
+		"50:text/x-csrc:*.c:cs\n" +
+		"50:text/x-c++src:*.C:cs\n" +
+		"50:text/x-makefile:Makefile:cs\n" +
+		"50:text/x-readme:README*\n" +
+		"invalid line\n",
+	"aliases": "text/comma-separated-values text/csv\n" +
+		"application/x-gzip application/gzip\n",
+	"subclasses": "text/csv text/plain\n" +
+		"application/x-compressed-tar application/gzip\n",
+}
+
+// packageMIMEFixture is a system database whose packages aren't compiled.
+var packageMIMEFixture = mimeFixture{
+	"packages/test.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<mime-info xmlns="http://www.freedesktop.org/standards/shared-mime-info">
+  <mime-type type="application/x-test">
+    <comment>Test document</comment>
+    <comment xml:lang="fr">document de test</comment>
+    <comment xml:lang="fr_CA">document d'essai</comment>
+    <comment xml:lang="de">Testdokument</comment>
+    <glob pattern="*.test"/>
+    <glob pattern="*.TST" weight="60" case-sensitive="true"/>
+  </mime-type>
+  <mime-type type="text/csv">
+    <comment>CSV document</comment>
+    <glob pattern="*.csv"/>
+  </mime-type>
+</mime-info>
+`,
+}
+
+func TestReadGlobs2(t *testing.T) {
+	dirs := useMIMEDatabase(t, systemMIMEFixture)
+
+	globs, err := readGlobs2(filepath.Join(dirs[0], "mime", "globs2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(globs) != 10 {
+		t.Fatalf("got %d globs, expected 10", len(globs))
+	}
+
+	expected := []mimeGlob{
+		{weight: 80, mimeType: "image/png", pattern: "*.png"},
+		{weight: 50, mimeType: "text/x-csrc", pattern: "*.c", caseSensitive: true},
+	}
+	for _, glob := range expected {
+		if !slices.Contains(globs, glob) {
+			t.Errorf("%+v not found in %+v", glob, globs)
+		}
+	}
+}
+
+func TestTypeByFilename(t *testing.T) {
+	useMIMEDatabase(t, userMIMEFixture, systemMIMEFixture, packageMIMEFixture)
+
+	tests := []struct {
+		name     string
+		mimeType string
+	}{
+		{name: "data.csv", mimeType: "text/x-user-csv"}, // The user database first.
+		{name: "notes.txt", mimeType: "text/plain"},
+		{name: "archive.gz", mimeType: "application/gzip"},
+		{name: "archive.tar.gz", mimeType: "application/x-compressed-tar"}, // The longest pattern first.
+		{name: "ARCHIVE.TAR.GZ", mimeType: "application/x-compressed-tar"},
+		{name: "image.png", mimeType: "image/png"}, // The highest weight first.
+		{name: "/tmp/dir.d/IMAGE.PNG", mimeType: "image/png"},
+		{name: "main.c", mimeType: "text/x-csrc"},
+		{name: "main.C", mimeType: "text/x-c++src"},
+		{name: "Makefile", mimeType: "text/x-makefile"},
+		{name: "README.md", mimeType: "text/x-readme"},
+		{name: "unit.test", mimeType: "application/x-test"}, // From the packages.
+		{name: "unit.TST", mimeType: "application/x-test"},
+		{name: "unknown.zzz", mimeType: ""},
+	}
+
+	for _, tt := range tests {
+		if mt := typeByFilename(tt.name); mt != tt.mimeType {
+			t.Errorf("%s: got %q, expected %q", tt.name, mt, tt.mimeType)
+		}
+	}
+}
+
+func TestMIMEAliasesAndSubclasses(t *testing.T) {
+	useMIMEDatabase(t, nil, systemMIMEFixture)
+
+	if mt := canonicalMIMEType("text/comma-separated-values"); mt != "text/csv" {
+		t.Errorf("got %q, expected text/csv", mt)
+	}
+	if mt := canonicalMIMEType("text/csv"); mt != "text/csv" {
+		t.Errorf("got %q, expected text/csv", mt)
+	}
+
+	tests := []struct {
+		mimeType, parent string
+		isA              bool
+	}{
+		{"text/csv", "text/plain", true},
+		{"text/comma-separated-values", "text/plain", true},
+		{"application/x-compressed-tar", "application/x-gzip", true},
+		{"text/plain", "text/plain", true},
+		{"text/plain", "text/csv", false},
+		{"image/png", "text/plain", false},
+	}
+	for _, tt := range tests {
+		if isA(tt.mimeType, tt.parent) != tt.isA {
+			t.Errorf("isA(%q, %q) isn't %v", tt.mimeType, tt.parent, tt.isA)
+		}
+	}
+}
+
+func TestExtensionsByType(t *testing.T) {
+	useMIMEDatabase(t, nil, systemMIMEFixture)
+
+	if exts := extensionsByType("application/x-gzip"); !slices.Contains(exts, ".gz") || slices.Contains(exts, ".tar.gz") {
+		t.Errorf("got %v for an alias, expected .gz only", exts)
+	}
+	if exts := extensionsByType("text/*"); !slices.Contains(exts, ".csv") || !slices.Contains(exts, ".txt") || slices.Contains(exts, ".png") {
+		t.Errorf("got %v for text/*", exts)
+	}
+}
+
+func TestMIMETypeComment(t *testing.T) {
+	tests := []struct {
+		lang    string
+		comment string
+	}{
+		{lang: "", comment: "Test document"},
+		{lang: "C", comment: "Test document"},
+		{lang: "es_ES.UTF-8", comment: "Test document"},
+		{lang: "fr_FR.UTF-8", comment: "document de test"},
+		{lang: "fr_CA.UTF-8", comment: "document d'essai"},
+		{lang: "de_DE@euro", comment: "Testdokument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			setLanguage(t, tt.lang)
+			useMIMEDatabase(t, nil, packageMIMEFixture)
+
+			if comment := mimeTypeComment("application/x-test"); comment != tt.comment {
+				t.Errorf("got %q, expected %q", comment, tt.comment)
+			}
+
+			// Only the languages of the user are loaded.
+			for lang := range mimeComments()["application/x-test"] {
+				if lang != "" && !slices.Contains(userLanguages(), lang) {
+					t.Errorf("comment in %q loaded", lang)
+				}
+			}
+		})
+	}
+}
+
+func TestUserLanguages(t *testing.T) {
+	tests := []struct {
+		lang      string
+		languages []string
+	}{
+		{lang: "", languages: nil},
+		{lang: "POSIX", languages: nil},
+		{lang: "fr_FR.UTF-8", languages: []string{"fr_FR", "fr"}},
+		{lang: "de_DE.UTF-8@euro", languages: []string{"de_DE@euro", "de_DE", "de@euro", "de"}},
+	}
+
+	for _, tt := range tests {
+		setLanguage(t, tt.lang)
+		if languages := userLanguages(); !slices.Equal(languages, tt.languages) {
+			t.Errorf("%q: got %v, expected %v", tt.lang, languages, tt.languages)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package gexplorer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const recentXBEL = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- Written by another application. -->
+<xbel version="1.0"
+      xmlns:bookmark="http://www.freedesktop.org/standards/desktop-bookmarks"
+      xmlns:mime="http://www.freedesktop.org/standards/shared-mime-info"
+      xmlns:x="http://example.org/x"
+>
+  <info>
+    <metadata owner="http://example.org"><x:setting>kept</x:setting></metadata>
+  </info>
+  <bookmark href="file:///tmp/used.txt" added="2024-01-01T00:00:00.000000Z" modified="2024-01-01T00:00:00.000000Z" visited="2024-01-01T00:00:00.000000Z">
+    <title>Used</title>
+    <info>
+      <metadata owner="http://freedesktop.org">
+        <mime:mime-type type="text/plain"/>
+        <bookmark:icon type="theme" href="text-x-generic"/>
+        <bookmark:applications>
+          <bookmark:application name="gedit" exec="&apos;gedit %u&apos;" modified="2024-01-01T00:00:00.000000Z" count="2"/>
+          <bookmark:application name="app" exec="&apos;app %u&apos;" modified="2024-01-01T00:00:00.000000Z" count="3"/>
+        </bookmark:applications>
+      </metadata>
+      <metadata owner="http://example.org"><foo>bar</foo></metadata>
+    </info>
+  </bookmark>
+  <bookmark href="file:///tmp/other.txt" added="2024-01-01T00:00:00.000000Z" modified="2024-01-01T00:00:00.000000Z" visited="2024-01-01T00:00:00.000000Z">
+    <info>
+      <metadata owner="http://freedesktop.org">
+        <mime:mime-type type="text/plain"/>
+        <bookmark:applications>
+          <bookmark:application name="gedit" exec="&apos;gedit %u&apos;" modified="2024-01-01T00:00:00.000000Z" count="1"/>
+        </bookmark:applications>
+      </metadata>
+    </info>
+  </bookmark>
+  <folder><title>Kept</title></folder>
+</xbel>
+`
+
+// writeXBEL writes the given content to a recently used files of a temporary directory.
+func writeXBEL(t *testing.T, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "recently-used.xbel")
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// readXBEL reads and parses the given recently used files.
+func readXBEL(t *testing.T, filename string) (string, *xbelFile) {
+	t.Helper()
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xbel, err := parseXBEL(data)
+	if err != nil {
+		t.Fatalf("%v in:\n%s", err, data)
+	}
+	return string(data), xbel
+}
+
+// recentFiles returns the recent files of the given XBEL file, by URI.
+func recentFiles(xbel *xbelFile) map[string]RecentFile {
+	files := map[string]RecentFile{}
+	for _, bookmark := range xbel.bookmarks {
+		files[bookmark.Href] = bookmark.recentFile()
+	}
+	return files
+}
+
+func TestParseXBEL(t *testing.T) {
+	xbel, err := parseXBEL([]byte(recentXBEL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := recentFiles(xbel)
+	if len(files) != 2 {
+		t.Fatalf("got %d bookmarks, expected 2", len(files))
+	}
+
+	used := files["file:///tmp/used.txt"]
+	if used.Path != "/tmp/used.txt" || used.MIMEType != "text/plain" || !slices.Equal(used.Applications, []string{"gedit", "app"}) {
+		t.Errorf("unexpected bookmark %+v", used)
+	}
+	if expected := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); !used.Modified.Equal(expected) {
+		t.Errorf("got modified %v, expected %v", used.Modified, expected)
+	}
+
+	if _, err := parseXBEL([]byte("<xbel>")); err == nil {
+		t.Error("a truncated file is parsed")
+	}
+}
+
+func TestAddRecentFiles(t *testing.T) {
+	filename := writeXBEL(t, recentXBEL)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	timestamp := now.Format(xbelTime)
+
+	paths := []string{"/tmp/used.txt", "/tmp/other.txt", "/tmp/new.png"}
+	if err := addRecentFiles(filename, "app", paths, now); err != nil {
+		t.Fatal(err)
+	}
+	data, xbel := readXBEL(t, filename)
+
+	// The content unknown by the recently used files is kept.
+	for _, kept := range []string{
+		"<!-- Written by another application. -->",
+		`<metadata owner="http://example.org"><x:setting>kept</x:setting></metadata>`,
+		"<title>Used</title>",
+		`<bookmark:icon type="theme" href="text-x-generic"/>`,
+		`<metadata owner="http://example.org"><foo>bar</foo></metadata>`,
+		`<bookmark:application name="gedit" exec="&apos;gedit %u&apos;" modified="2024-01-01T00:00:00.000000Z" count="2"/>`,
+		"<folder><title>Kept</title></folder>",
+	} {
+		if !strings.Contains(data, kept) {
+			t.Errorf("%s has been dropped from:\n%s", kept, data)
+		}
+	}
+
+	files := recentFiles(xbel)
+	if len(files) != 3 {
+		t.Fatalf("got %d bookmarks, expected 3:\n%s", len(files), data)
+	}
+	for _, path := range paths {
+		file := files["file://"+path]
+		if !file.Modified.Equal(now) {
+			t.Errorf("%s: got modified %v, expected %v", path, file.Modified, now)
+		}
+		if !slices.Contains(file.Applications, "app") {
+			t.Errorf("%s: app not recorded in %v", path, file.Applications)
+		}
+	}
+	if file := files["file:///tmp/new.png"]; file.MIMEType != "image/png" {
+		t.Errorf("got MIME type %q for the new bookmark, expected image/png", file.MIMEType)
+	}
+
+	// The existing application entry is updated, the missing one is added.
+	if !strings.Contains(data, `<bookmark:application name="app" exec="&apos;app %u&apos;" modified="`+timestamp+`" count="4"/>`) {
+		t.Errorf("the application entry isn't updated in:\n%s", data)
+	}
+	if strings.Count(data, `name="app"`) != 3 {
+		t.Errorf("expected an application entry by bookmark in:\n%s", data)
+	}
+
+	// Once recorded, the file is stable.
+	if err := addRecentFiles(filename, "app", paths, now); err != nil {
+		t.Fatal(err)
+	}
+	again, _ := readXBEL(t, filename)
+	if again != strings.ReplaceAll(strings.ReplaceAll(data, `count="4"`, `count="5"`), `count="1"/>`+"\n"+`        </bookmark:applications>`, `count="2"/>`+"\n"+`        </bookmark:applications>`) {
+		t.Errorf("unexpected changes:\n%s", again)
+	}
+}
+
+func TestAddRecentFilesElements(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "new file", content: ""},
+		{name: "no namespaces", content: `<xbel version="1.0"><bookmark href="file:///tmp/a.txt"/></xbel>`},
+		{name: "no info", content: `<xbel version="1.0"><bookmark href="file:///tmp/a.txt"></bookmark></xbel>`},
+		{name: "empty info", content: `<xbel version="1.0"><bookmark href="file:///tmp/a.txt"><info/></bookmark></xbel>`},
+		{name: "foreign metadata", content: `<xbel version="1.0"><bookmark href="file:///tmp/a.txt"><info><metadata owner="http://example.org"><foo>bar</foo></metadata></info></bookmark></xbel>`},
+		{name: "no applications", content: `<xbel version="1.0"><bookmark href="file:///tmp/a.txt"><info><metadata owner="http://freedesktop.org"/></info></bookmark></xbel>`},
+		{name: "empty applications", content: "<xbel version=\"1.0\" xmlns:bookmark=\"" + xbelBookmarkNamespace + "\">\n" +
+			"  <bookmark href=\"file:///tmp/a.txt\">\n" +
+			"    <info>\n" +
+			"      <metadata owner=\"http://freedesktop.org\">\n" +
+			"        <bookmark:applications>\n" +
+			"        </bookmark:applications>\n" +
+			"      </metadata>\n" +
+			"    </info>\n" +
+			"  </bookmark>\n" +
+			"</xbel>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := writeXBEL(t, tt.content)
+			if err := addRecentFiles(filename, "app", []string{"/tmp/a.txt"}, now); err != nil {
+				t.Fatal(err)
+			}
+
+			data, xbel := readXBEL(t, filename)
+			file := recentFiles(xbel)["file:///tmp/a.txt"]
+			if !file.Modified.Equal(now) || !slices.Equal(file.Applications, []string{"app"}) || file.MIMEType != "text/plain" {
+				t.Errorf("unexpected bookmark %+v in:\n%s", file, data)
+			}
+			if strings.Contains(tt.content, "<foo>bar</foo>") && !strings.Contains(data, "<foo>bar</foo>") {
+				t.Errorf("the foreign metadata has been dropped from:\n%s", data)
+			}
+		})
+	}
+}
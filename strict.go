@@ -0,0 +1,96 @@
+package gexplorer
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"slices"
+)
+
+// sniffLength is the number of bytes read to detect the type of a content.
+const sniffLength = 512
+
+// genericContentTypes are the detected types too vague to contradict the type of a filename
+// (such as `text/plain` for a CSV file or `application/zip` for an OpenDocument file).
+var genericContentTypes = []string{"application/octet-stream", "text/plain", "application/zip", "text/xml", "application/xml"}
+
+// checkSelection returns a FilterMismatchError listing the selected files not matching
+// the filters of the given options in strict mode, nil otherwise.
+// The selections of directories and of options without filters are never checked.
+func checkSelection(opts *Options, selection *Selection, sniff bool) error {
+	if !opts.Strict || opts.Directory {
+		return nil
+	}
+
+	filters := opts.filters()
+	if len(filters) == 0 {
+		return nil
+	}
+
+	var mismatches []string
+	for _, path := range selection.Paths {
+		if !matchFilters(filters, path, sniff) {
+			mismatches = append(mismatches, path)
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return &FilterMismatchError{Paths: mismatches, Selection: selection}
+	}
+	return nil
+}
+
+// matchFilters reports whether the given file matches one of the given filters.
+// When sniffing, the content of the file must not contradict its name, or must have one of the MIME types of the filters.
+func matchFilters(filters []Filter, path string, sniff bool) bool {
+	var contentType string
+	if sniff {
+		contentType = detectContentType(path)
+	}
+
+	for _, filter := range filters {
+		if contentType != "" {
+			for _, pattern := range filter.MIMETypes {
+				if matchMIMEType(pattern, contentType) || isA(contentType, pattern) {
+					return true
+				}
+			}
+		}
+
+		if filter.match(path) && (contentType == "" || consistentContentType(path, contentType)) {
+			return true
+		}
+	}
+	return false
+}
+
+// consistentContentType reports whether the given detected type doesn't contradict the type of the given filename.
+func consistentContentType(name, contentType string) bool {
+	mt := typeByFilename(name)
+	if mt == "" || mt == contentType || slices.Contains(genericContentTypes, contentType) {
+		return true
+	}
+	return isA(mt, contentType) || isA(contentType, mt)
+}
+
+// detectContentType returns the MIME type of the content of the given file, empty when it can't be read.
+func detectContentType(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	data := make([]byte, sniffLength)
+	n, err := io.ReadFull(f, data)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "" // Empty or unreadable.
+	}
+
+	mt, _, err := mime.ParseMediaType(http.DetectContentType(data[:n]))
+	if err != nil {
+		return ""
+	}
+	return canonicalMIMEType(mt)
+}
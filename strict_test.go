@@ -0,0 +1,90 @@
+package gexplorer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// pngHeader is the signature starting the PNG files.
+const pngHeader = "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"
+
+func TestCheckSelection(t *testing.T) {
+	useMIMEDatabase(t, nil, systemMIMEFixture)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"data.csv":    "name,value\na,1\n",
+		"notes.txt":   "Some notes.\n",
+		"image.png":   pngHeader,
+		"renamed.csv": pngHeader,          // A PNG image renamed.
+		"image":       pngHeader,          // A PNG image without extension.
+		"binary.csv":  "\x00\x01\x02\x03", // Detected as application/octet-stream.
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		opts       Options
+		sniff      bool
+		paths      []string
+		mismatches []string
+	}{
+		{name: "not strict", opts: Options{Extensions: []string{".csv"}}, paths: []string{"image.png"}},
+		{name: "no filters", opts: Options{Strict: true}, paths: []string{"image.png"}},
+		{name: "directories", opts: Options{Strict: true, Directory: true, Extensions: []string{".csv"}}, paths: []string{"."}},
+		{name: "extension", opts: Options{Strict: true, Extensions: []string{".csv"}}, paths: []string{"data.csv"}},
+		{name: "mismatches", opts: Options{Strict: true, Extensions: []string{".csv"}}, paths: []string{"image.png", "data.csv", "notes.txt"}, mismatches: []string{"image.png", "notes.txt"}},
+		{name: "filters", opts: Options{Strict: true, Extensions: []string{".csv"}, Filters: []Filter{{Name: "Images", MIMETypes: []string{"image/*"}}}}, paths: []string{"image.png", "data.csv"}},
+		{name: "subclass", opts: Options{Strict: true, Extensions: []string{"text/plain"}}, paths: []string{"data.csv", "notes.txt"}},
+		{name: "subclass sniffed", opts: Options{Strict: true, Extensions: []string{"text/plain"}}, sniff: true, paths: []string{"data.csv", "notes.txt"}},
+		{name: "renamed", opts: Options{Strict: true, Extensions: []string{".csv"}}, paths: []string{"renamed.csv"}},
+		{name: "renamed sniffed", opts: Options{Strict: true, Extensions: []string{".csv"}}, sniff: true, paths: []string{"renamed.csv", "data.csv"}, mismatches: []string{"renamed.csv"}},
+		{name: "content sniffed", opts: Options{Strict: true, Extensions: []string{"image/png"}}, sniff: true, paths: []string{"image", "image.png"}},
+		{name: "content not sniffed", opts: Options{Strict: true, Extensions: []string{"image/png"}}, paths: []string{"image"}, mismatches: []string{"image"}},
+		{name: "generic text sniffed", opts: Options{Strict: true, Extensions: []string{".csv"}}, sniff: true, paths: []string{"data.csv"}},
+		{name: "generic binary sniffed", opts: Options{Strict: true, Extensions: []string{".csv"}}, sniff: true, paths: []string{"binary.csv"}},
+		{name: "missing file sniffed", opts: Options{Strict: true, Extensions: []string{".csv"}}, sniff: true, paths: []string{"new.csv"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths, mismatches []string
+			for _, path := range tt.paths {
+				paths = append(paths, filepath.Join(dir, path))
+			}
+			for _, path := range tt.mismatches {
+				mismatches = append(mismatches, filepath.Join(dir, path))
+			}
+			selection := NewSelection(paths)
+
+			err := checkSelection(&tt.opts, selection, tt.sniff)
+			if len(mismatches) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrFilterMismatch) {
+				t.Fatalf("got %v, expected %v", err, ErrFilterMismatch)
+			}
+			var mismatch *FilterMismatchError
+			if !errors.As(err, &mismatch) {
+				t.Fatalf("got %T, expected %T", err, mismatch)
+			}
+			if !slices.Equal(mismatch.Paths, mismatches) {
+				t.Errorf("got mismatches %v, expected %v", mismatch.Paths, mismatches)
+			}
+			if mismatch.Selection != selection {
+				t.Error("the selection isn't reported")
+			}
+		})
+	}
+}
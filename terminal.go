@@ -0,0 +1,472 @@
+package gexplorer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+func init() {
+	RegisterBackend("terminal", func(_ RunHandler) Backend {
+		return stdinTerminal()
+	})
+}
+
+// stdinTerminal returns the backend prompting the user on stdin/stdout.
+// It's shared, so only one goroutine reads stdin.
+var stdinTerminal = sync.OnceValue(func() Backend {
+	return NewTerminalBackend(os.Stdin, os.Stdout)
+})
+
+// terminal is a line-oriented file explorer, for SSH and headless sessions.
+// The user navigates through the directories and selects the entries by their number, or by typing paths.
+type terminal struct {
+	in  io.Reader
+	out io.Writer
+
+	once   sync.Once
+	notify chan struct{} // Signaled when a line is queued or in is consumed.
+
+	mutex  sync.Mutex
+	active int      // The number of dialogs being shown.
+	lines  []string // The lines typed while a dialog is shown, not yet read.
+	eof    bool     // The input is consumed.
+}
+
+// NewTerminalBackend returns a Backend prompting the user on a terminal, such as os.Stdin and os.Stdout.
+// The `terminal` backend uses them and NewExplorer uses it when no other backend is available
+// and stdin is a terminal.
+//
+// The lines typed while no dialog is shown are discarded, so a line typed after a dialog
+// has been dismissed isn't handled by the next one.
+func NewTerminalBackend(in io.Reader, out io.Writer) Backend {
+	return &terminal{
+		in:     in,
+		out:    out,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// stdinIsTerminal reports whether stdin is a terminal, and not a pipe or a file.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// OpenFile implements Backend.
+func (t *terminal) OpenFile(ctx context.Context, opts *Options) (*Selection, error) {
+	return t.run(ctx, withMode(opts, false, false), false)
+}
+
+// OpenFiles implements Backend.
+func (t *terminal) OpenFiles(ctx context.Context, opts *Options) (*Selection, error) {
+	return t.run(ctx, withMode(opts, true, false), false)
+}
+
+// SaveFile implements Backend.
+func (t *terminal) SaveFile(ctx context.Context, opts *Options) (*Selection, error) {
+	return t.run(ctx, opts, true)
+}
+
+// OpenDirectory implements Backend.
+func (t *terminal) OpenDirectory(ctx context.Context, opts *Options) (*Selection, error) {
+	return t.run(ctx, withMode(opts, opts.Multiple, true), false)
+}
+
+// Capabilities implements CapabilitiesReporter.
+func (t *terminal) Capabilities() (Capabilities, error) {
+	return Capabilities{
+		OpenFile:        true,
+		OpenFiles:       true,
+		SaveFile:        true,
+		SaveFiles:       true,
+		OpenDirectory:   true,
+		OpenDirectories: true,
+		Filters:         true,
+		Folder:          true,
+	}, nil
+}
+
+// run prompts the user until the selection is done.
+func (t *terminal) run(ctx context.Context, opts *Options, save bool) (*Selection, error) {
+	t.begin()
+	defer t.end()
+
+	s := &session{
+		opts:    opts,
+		save:    save,
+		filters: opts.filters(),
+	}
+	if err := s.start(); err != nil {
+		return nil, err
+	}
+
+	label := openLabel(opts)
+	if save {
+		label = "Choose Save Location"
+	}
+	fmt.Fprintf(t.out, "%s\n", title(opts, label))
+
+	for {
+		entries, err := s.list()
+		if err != nil {
+			return nil, err
+		}
+		s.print(t.out, entries)
+
+		fmt.Fprint(t.out, "> ")
+		line, err := t.readLine(ctx)
+		if err != nil {
+			fmt.Fprintln(t.out)
+			return nil, err
+		}
+
+		paths, err := s.handle(ctx, t, entries, line)
+		if err != nil {
+			return nil, err
+		}
+		if paths == nil {
+			continue
+		}
+
+		selection := NewSelection(paths)
+		selection.Choices = completeChoices(nil, opts.Choices)
+		selection.Writable = true
+		return selection, nil
+	}
+}
+
+// begin starts a dialog, the lines typed by the user being queued until it ends.
+// The input is read by a goroutine, so a dialog is dismissed as soon as the context is done.
+func (t *terminal) begin() {
+	t.mutex.Lock()
+	t.active++
+	t.mutex.Unlock()
+
+	t.once.Do(func() {
+		go func() {
+			scanner := bufio.NewScanner(t.in)
+			for scanner.Scan() {
+				t.mutex.Lock()
+				if t.active > 0 {
+					t.lines = append(t.lines, scanner.Text())
+				}
+				t.mutex.Unlock()
+				t.signal()
+			}
+
+			t.mutex.Lock()
+			t.eof = true
+			t.mutex.Unlock()
+			t.signal()
+		}()
+	})
+}
+
+// end ends a dialog, the lines it didn't read being discarded once no dialog is shown.
+func (t *terminal) end() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.active--
+	if t.active == 0 {
+		t.lines = nil
+	}
+}
+
+// signal wakes up the dialog waiting for a line, if any.
+func (t *terminal) signal() {
+	select {
+	case t.notify <- struct{}{}:
+	default:
+	}
+}
+
+// readLine reads the next line typed by the user.
+func (t *terminal) readLine(ctx context.Context) (string, error) {
+	for {
+		t.mutex.Lock()
+		switch {
+		case len(t.lines) > 0:
+			line := t.lines[0]
+			t.lines = t.lines[1:]
+			t.mutex.Unlock()
+			return strings.TrimSpace(line), nil
+		case t.eof:
+			t.mutex.Unlock()
+			return "", ErrUserDecline // End of input, such as Ctrl+D.
+		}
+		t.mutex.Unlock()
+
+		select {
+		case <-t.notify:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// session is the state of a terminal dialog.
+type session struct {
+	opts    *Options
+	save    bool
+	filters []Filter
+
+	dir      string   // The directory being listed.
+	name     string   // The name of the file to create.
+	all      bool     // Shows the files not matching the filters.
+	selected []string // The paths selected so far, in order.
+}
+
+// entry is a listed file or directory.
+type entry struct {
+	name string
+	dir  bool
+}
+
+// start initializes the directory listed first.
+func (s *session) start() error {
+	s.dir, s.name = s.opts.Folder, s.opts.Name
+	if s.save && s.opts.File != "" {
+		s.name = filepath.Base(s.opts.File)
+		if s.dir == "" {
+			s.dir = filepath.Dir(s.opts.File)
+		}
+	}
+
+	if s.dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		s.dir = wd
+	}
+
+	dir, err := filepath.Abs(s.dir)
+	if err != nil {
+		return err
+	}
+	s.dir = dir
+	return nil
+}
+
+// list lists the current directory, the directories first.
+// The parent directory is always the first entry.
+func (s *session) list() ([]entry, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []entry{{name: "..", dir: true}}
+	var regulars []entry
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") {
+			continue // Hidden files can still be typed.
+		}
+
+		isDir := file.IsDir()
+		if file.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(s.dir, file.Name())); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+
+		switch {
+		case isDir:
+			entries = append(entries, entry{name: file.Name(), dir: true})
+		case s.opts.Directory:
+			// Only directories can be selected.
+		case s.all || s.match(file.Name()):
+			regulars = append(regulars, entry{name: file.Name()})
+		}
+	}
+
+	return append(entries, regulars...), nil
+}
+
+// match reports whether the given filename matches the filters.
+func (s *session) match(name string) bool {
+	if len(s.filters) == 0 {
+		return true
+	}
+
+	for _, filter := range s.filters {
+		if filter.match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// print prints the entries of the current directory and the available commands.
+func (s *session) print(w io.Writer, entries []entry) {
+	fmt.Fprintf(w, "\n%s\n", s.dir)
+	for i, entry := range entries {
+		mark := " "
+		if slices.Contains(s.selected, filepath.Join(s.dir, entry.name)) {
+			mark = "*"
+		}
+
+		name := entry.name
+		if entry.dir {
+			name += string(filepath.Separator)
+		}
+		fmt.Fprintf(w, "%s %3d  %s\n", mark, i, name)
+	}
+
+	var help []string
+	switch {
+	case s.save:
+		help = append(help, "a number to open a directory", "a file name to create it in this directory")
+		if s.name != "" {
+			help = append(help, fmt.Sprintf("an empty line to create %q", s.name))
+		}
+	case s.opts.Directory:
+		help = append(help, "a number to open a directory", `"." to select this directory`)
+	default:
+		help = append(help, "a number to open a directory or select a file")
+	}
+	if s.opts.Multiple && !s.save {
+		help = append(help, `"+number" to add or remove a selection`, "an empty line to confirm the selection")
+	}
+	if len(s.filters) > 0 && !s.opts.Directory {
+		if s.all {
+			help = append(help, `"*" to show only the matching files`)
+		} else {
+			help = append(help, `"*" to show all files`)
+		}
+	}
+	help = append(help, `"q" to cancel`)
+
+	fmt.Fprintf(w, "Enter a path, %s.\n", strings.Join(help, ", "))
+}
+
+// handle handles the given line typed by the user.
+// It returns the selected paths once the selection is done.
+func (s *session) handle(ctx context.Context, t *terminal, entries []entry, line string) ([]string, error) {
+	switch {
+	case line == "q":
+		return nil, ErrUserDecline
+	case line == "*":
+		s.all = !s.all
+		return nil, nil
+	case line == "" && s.save && s.name != "":
+		return s.create(ctx, t, filepath.Join(s.dir, s.name))
+	case line == "" && s.opts.Multiple && len(s.selected) > 0:
+		return s.selected, nil
+	case line == "":
+		return nil, nil
+	case line == "." && s.opts.Directory && !s.save:
+		return s.choose(s.dir), nil
+	}
+
+	if n, err := strconv.Atoi(strings.TrimPrefix(line, "+")); err == nil {
+		if n < 0 || n >= len(entries) {
+			fmt.Fprintf(t.out, "No entry %d.\n", n)
+			return nil, nil
+		}
+
+		path := filepath.Join(s.dir, entries[n].name)
+		if strings.HasPrefix(line, "+") && s.opts.Multiple && !s.save {
+			if entries[n].dir == s.opts.Directory {
+				s.toggle(path)
+			}
+			return nil, nil
+		}
+		return s.open(ctx, t, path, entries[n].dir)
+	}
+
+	path := line
+	if strings.HasPrefix(path, "~"+string(filepath.Separator)) || path == "~" {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.dir, path)
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		return s.open(ctx, t, path, info.IsDir())
+	case errors.Is(err, os.ErrNotExist) && s.save:
+		return s.create(ctx, t, path)
+	default:
+		fmt.Fprintf(t.out, "%v.\n", err)
+		return nil, nil
+	}
+}
+
+// open opens the given directory, or selects the given file.
+func (s *session) open(ctx context.Context, t *terminal, path string, dir bool) ([]string, error) {
+	switch {
+	case dir:
+		if _, err := os.ReadDir(path); err != nil {
+			fmt.Fprintf(t.out, "%v.\n", err)
+			return nil, nil
+		}
+		s.dir = path
+		return nil, nil
+	case s.save:
+		return s.create(ctx, t, path)
+	case s.opts.Directory:
+		fmt.Fprintf(t.out, "%s is not a directory.\n", path)
+		return nil, nil
+	default:
+		return s.choose(path), nil
+	}
+}
+
+// choose selects the given path. When multiple paths can be selected, it's added to
+// or removed from the selection instead.
+func (s *session) choose(path string) []string {
+	if !s.opts.Multiple {
+		return []string{path}
+	}
+
+	s.toggle(path)
+	return nil
+}
+
+// toggle adds the given path to the selection, or removes it if already selected.
+func (s *session) toggle(path string) {
+	if i := slices.Index(s.selected, path); i >= 0 {
+		s.selected = slices.Delete(s.selected, i, i+1)
+		return
+	}
+	s.selected = append(s.selected, path)
+}
+
+// create selects the given path as the file to create, asking for confirmation when it already exists.
+func (s *session) create(ctx context.Context, t *terminal, path string) ([]string, error) {
+	if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+		fmt.Fprintf(t.out, "%s is not a directory.\n", filepath.Dir(path))
+		return nil, nil
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		fmt.Fprintf(t.out, "%s already exists, replace it? [y/N] ", path)
+		line, err := t.readLine(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(line, "y") && !strings.EqualFold(line, "yes") {
+			return nil, nil
+		}
+	}
+
+	return []string{path}, nil
+}
@@ -0,0 +1,164 @@
+package gexplorer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mdouchement/gexplorer"
+)
+
+// terminalTree creates the tree browsed by the terminal tests, listed as:
+//
+//	0  ..
+//	1  sub/
+//	2  a.csv
+//	3  b.png
+//
+// sub only contains c.csv.
+func terminalTree(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.csv", "b.png", filepath.Join("sub", "c.csv")} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestTerminal(t *testing.T) {
+	dir := terminalTree(t)
+
+	tests := []struct {
+		name  string
+		opts  gexplorer.Options
+		save  bool
+		input string
+		paths []string // Relative to dir.
+		err   error
+	}{
+		{name: "number", input: "2\n", paths: []string{"a.csv"}},
+		{name: "directory number", input: "1\n1\n", paths: []string{"sub/c.csv"}},
+		{name: "parent", opts: gexplorer.Options{Folder: filepath.Join(dir, "sub")}, input: "0\n3\n", paths: []string{"b.png"}},
+		{name: "relative path", input: "sub\nc.csv\n", paths: []string{"sub/c.csv"}},
+		{name: "absolute path", input: filepath.Join(dir, "b.png") + "\n", paths: []string{"b.png"}},
+		{name: "unknown entry", input: "9\n", err: gexplorer.ErrUserDecline},
+		{name: "multiple", opts: gexplorer.Options{Multiple: true}, input: "+2\n+3\n\n", paths: []string{"a.csv", "b.png"}},
+		{name: "multiple toggle", opts: gexplorer.Options{Multiple: true}, input: "+2\n+3\n+2\n\n", paths: []string{"b.png"}},
+		{name: "multiple across directories", opts: gexplorer.Options{Multiple: true}, input: "+2\n1\n+1\n\n", paths: []string{"a.csv", "sub/c.csv"}},
+		{name: "directory", opts: gexplorer.Options{Directory: true}, input: ".\n", paths: []string{"."}},
+		{name: "directory by number", opts: gexplorer.Options{Directory: true}, input: "1\n.\n", paths: []string{"sub"}},
+		{name: "filtered", opts: gexplorer.Options{Extensions: []string{".csv"}}, input: "3\n", err: gexplorer.ErrUserDecline},
+		{name: "filter toggle", opts: gexplorer.Options{Extensions: []string{".csv"}}, input: "*\n3\n", paths: []string{"b.png"}},
+		{name: "filter toggle back", opts: gexplorer.Options{Extensions: []string{".csv"}}, input: "*\n*\n3\n", err: gexplorer.ErrUserDecline},
+		{name: "save name", opts: gexplorer.Options{Name: "new.txt"}, save: true, input: "\n", paths: []string{"new.txt"}},
+		{name: "save typed name", save: true, input: "sub\nout.txt\n", paths: []string{"sub/out.txt"}},
+		{name: "save overwrite", opts: gexplorer.Options{Name: "a.csv"}, save: true, input: "\ny\n", paths: []string{"a.csv"}},
+		{name: "save overwrite refused", opts: gexplorer.Options{Name: "a.csv"}, save: true, input: "\nn\n", err: gexplorer.ErrUserDecline},
+		{name: "save overwrite number", save: true, input: "3\nyes\n", paths: []string{"b.png"}},
+		{name: "quit", input: "q\n", err: gexplorer.ErrUserDecline},
+		{name: "quit save", save: true, input: "sub\nq\n", err: gexplorer.ErrUserDecline},
+		{name: "end of input", input: "", err: gexplorer.ErrUserDecline},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := gexplorer.NewExplorerWithBackend(nil, gexplorer.NewTerminalBackend(strings.NewReader(tt.input), io.Discard))
+
+			opts := tt.opts
+			if opts.Folder == "" {
+				opts.Folder = dir
+			}
+
+			var selection *gexplorer.Selection
+			var err error
+			if tt.save {
+				selection, err = e.Save(context.Background(), &opts)
+			} else {
+				selection, err = e.Open(context.Background(), &opts)
+			}
+
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got error %v, expected %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var paths []string
+			for _, path := range tt.paths {
+				paths = append(paths, filepath.Join(dir, filepath.FromSlash(path)))
+			}
+			if !slices.Equal(selection.Paths, paths) {
+				t.Errorf("got %v, expected %v", selection.Paths, paths)
+			}
+		})
+	}
+}
+
+// promptWriter signals each prompt of the terminal.
+type promptWriter chan struct{}
+
+func (w promptWriter) Write(p []byte) (int, error) {
+	if strings.HasSuffix(string(p), "> ") {
+		select {
+		case w <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+func TestTerminalCancel(t *testing.T) {
+	dir := terminalTree(t)
+
+	in, input := io.Pipe()
+	defer input.Close()
+	prompts := make(promptWriter, 1)
+	backend := gexplorer.NewTerminalBackend(in, prompts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := backend.OpenFile(ctx, &gexplorer.Options{Folder: dir}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, expected %v", err, context.Canceled)
+	}
+	<-prompts
+
+	// Typed while no dialog is shown, so it's discarded. The pipe returns once the next line
+	// is read, so the first one has been handled; the next one is an unknown path, ignored if received.
+	for _, line := range []string{"q\n", "unknown\n"} {
+		if _, err := io.WriteString(input, line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan *gexplorer.Selection, 1)
+	go func() {
+		selection, err := backend.OpenFile(context.Background(), &gexplorer.Options{Folder: dir})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- selection
+	}()
+
+	<-prompts
+	if _, err := io.WriteString(input, "2\n"); err != nil {
+		t.Fatal(err)
+	}
+	if selection := <-done; selection != nil && !slices.Equal(selection.Paths, []string{filepath.Join(dir, "a.csv")}) {
+		t.Errorf("got %v, expected a.csv", selection.Paths)
+	}
+}